@@ -0,0 +1,65 @@
+// Command golden renders every scene description in a directory headlessly
+// and compares it to its reference image
+//
+// It needs an EGL capable driver (e.g. Mesa's llvmpipe) and a build with -tags egl:
+//
+//	go run -tags egl ./cmd/golden
+//	go run -tags egl ./cmd/golden -update res/golden/crates.json
+//
+// Descriptions are JSON files, see golden.Description. Without arguments,
+// every description in -dir is checked
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+
+	"github.com/linosgian/goph3d/golden"
+)
+
+func init() {
+	runtime.LockOSThread() // GL calls must all come from the same thread
+}
+
+func main() {
+	update := flag.Bool("update", false, "write the rendered images as the new references instead of comparing")
+	dir := flag.String("dir", "res/golden", "directory of the scene descriptions")
+	flag.Parse()
+
+	paths := flag.Args()
+	if len(paths) == 0 {
+		var err error
+		if paths, err = filepath.Glob(filepath.Join(*dir, "*.json")); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+		if len(paths) == 0 {
+			fmt.Fprintf(os.Stderr, "no scene descriptions in %s\n", *dir)
+			os.Exit(2)
+		}
+	}
+
+	failed := 0
+	for _, path := range paths {
+		rep, err := golden.Check(path, *update)
+		switch {
+		case err != nil:
+			failed++
+			fmt.Printf("FAIL %s: %v\n", path, err)
+			if rep != nil && rep.Diff != "" {
+				fmt.Printf("     diff: %s\n", rep.Diff)
+			}
+		case rep.Updated:
+			fmt.Printf("UPD  %s\n", path)
+		default:
+			fmt.Printf("ok   %s: %v\n", path, rep.Result)
+		}
+	}
+	if failed > 0 {
+		fmt.Printf("%d of %d scenes failed\n", failed, len(paths))
+		os.Exit(1)
+	}
+}
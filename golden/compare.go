@@ -0,0 +1,78 @@
+package golden
+
+import (
+	"fmt"
+	"image"
+	"image/draw"
+)
+
+// How much two images differ
+type Result struct {
+	Mismatched int   // Pixels with a channel beyond the tolerance
+	Total      int   // Pixels compared
+	MaxDiff    uint8 // Largest difference of any channel
+}
+
+// Fraction of pixels that mismatched
+func (res Result) MismatchRatio() float64 {
+	if res.Total == 0 {
+		return 0
+	}
+	return float64(res.Mismatched) / float64(res.Total)
+}
+
+func (res Result) String() string {
+	return fmt.Sprintf("%d of %d pixels differ (%.3f%%), max channel difference %d",
+		res.Mismatched, res.Total, 100*res.MismatchRatio(), res.MaxDiff)
+}
+
+// Compares two images pixel by pixel, a pixel matches when no channel differs by more than tolerance
+// Returns a diff image as well: the reference dimmed to gray, with mismatching pixels in red
+func Compare(got, want image.Image, tolerance uint8) (Result, *image.NRGBA, error) {
+	if got.Bounds().Size() != want.Bounds().Size() {
+		return Result{}, nil, fmt.Errorf("image is %v, the reference is %v", got.Bounds().Size(), want.Bounds().Size())
+	}
+	g, w := toNRGBA(got), toNRGBA(want)
+	diff := image.NewNRGBA(w.Rect)
+
+	var res Result
+	for i := 0; i < len(w.Pix); i += 4 {
+		var worst uint8
+		for c := 0; c < 4; c++ {
+			if d := absDiff(g.Pix[i+c], w.Pix[i+c]); d > worst {
+				worst = d
+			}
+		}
+		if worst > res.MaxDiff {
+			res.MaxDiff = worst
+		}
+		res.Total++
+
+		if worst > tolerance {
+			res.Mismatched++
+			copy(diff.Pix[i:i+4], []uint8{0xff, 0, 0, 0xff})
+			continue
+		}
+		// Luma of the reference at a third of its brightness, so red stands out
+		y := uint8((299*uint32(w.Pix[i]) + 587*uint32(w.Pix[i+1]) + 114*uint32(w.Pix[i+2])) / 3000)
+		copy(diff.Pix[i:i+4], []uint8{y, y, y, 0xff})
+	}
+	return res, diff, nil
+}
+
+func absDiff(a, b uint8) uint8 {
+	if a > b {
+		return a - b
+	}
+	return b - a
+}
+
+// Returns the image as NRGBA with its origin at 0,0
+func toNRGBA(im image.Image) *image.NRGBA {
+	if n, ok := im.(*image.NRGBA); ok && n.Rect.Min == (image.Point{}) && n.Stride == 4*n.Rect.Dx() {
+		return n
+	}
+	n := image.NewNRGBA(image.Rect(0, 0, im.Bounds().Dx(), im.Bounds().Dy()))
+	draw.Draw(n, n.Rect, im, im.Bounds().Min, draw.Src)
+	return n
+}
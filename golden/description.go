@@ -0,0 +1,178 @@
+// Package golden renders scenes described in JSON files headlessly
+// and compares them to reference images, see cmd/golden
+package golden
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/linosgian/goph3d/loader/obj"
+	"github.com/linosgian/goph3d/renderer"
+	"github.com/linosgian/goph3d/scene"
+)
+
+// Defaults of a description
+const (
+	defaultWidth     = 320
+	defaultHeight    = 180
+	defaultDeltaTime = 1.0 / 60
+)
+
+// A scene to render and how closely it must match its reference
+// Paths are relative to the description file
+type Description struct {
+	Width, Height int     // Of the rendered image
+	Frames        int     // Frames to draw, the last one is compared
+	DeltaTime     float64 // Fixed time between frames, in seconds
+
+	// Largest difference of any channel a pixel may have and still match
+	Tolerance uint8
+	// Fraction of pixels that may mismatch before the comparison fails
+	MaxMismatch float64
+
+	Camera struct {
+		Position mgl32.Vec3
+		Front    *mgl32.Vec3 // Looks down -Z when missing
+	}
+	PointLights []scene.PointLight
+	Skybox      string // Equirectangular panorama, optional
+	Nodes       []NodeDescription
+}
+
+// An OBJ model placed in the scene
+type NodeDescription struct {
+	Name     string
+	Model    string
+	Program  string // "phong" when empty
+	Texture  string // Diffuse map, optional
+	Position mgl32.Vec3
+	Rotation mgl32.Vec3  // Euler angles in degrees, applied in X, Y, Z order
+	Scale    *mgl32.Vec3 // 1 when missing
+
+	// Material colors, the material's own when missing
+	Diffuse, Specular *mgl32.Vec3
+	Shininess         *float32
+}
+
+// Reads a description file, filling in the defaults
+func LoadDescription(path string) (*Description, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not read description: %v", err)
+	}
+	d := &Description{
+		Width:     defaultWidth,
+		Height:    defaultHeight,
+		Frames:    1,
+		DeltaTime: defaultDeltaTime,
+	}
+	if err := json.Unmarshal(data, d); err != nil {
+		return nil, fmt.Errorf("could not parse %s: %v", path, err)
+	}
+	if d.Width <= 0 || d.Height <= 0 || d.Frames <= 0 || d.DeltaTime < 0 {
+		return nil, fmt.Errorf("%s: invalid size, frame count or time step", path)
+	}
+	if d.MaxMismatch < 0 || d.MaxMismatch > 1 {
+		return nil, fmt.Errorf("%s: MaxMismatch must be a fraction between 0 and 1", path)
+	}
+	for _, nd := range d.Nodes {
+		if nd.Model == "" {
+			return nil, fmt.Errorf("%s: node %q has no model", path, nd.Name)
+		}
+	}
+	return d, nil
+}
+
+// Builds the scene the description describes
+// dir is what relative paths are resolved against
+func (d *Description) Build(r *renderer.Renderer, dir string) (*scene.Scene, error) {
+	cam := scene.NewCamera(d.Camera.Position, float64(d.Width)/2, float64(d.Height)/2)
+	if d.Camera.Front != nil {
+		cam.LookAlong(*d.Camera.Front)
+	}
+	lights := make([]*scene.PointLight, len(d.PointLights))
+	for i := range d.PointLights {
+		lights[i] = &d.PointLights[i]
+	}
+	sc := scene.NewScene(float32(d.Width)/float32(d.Height), cam, lights)
+
+	if d.Skybox != "" {
+		texID, err := r.LoadEquirectCubemap(resolve(dir, d.Skybox), 512, renderer.CubemapTextureOptions())
+		if err != nil {
+			return nil, err
+		}
+		if err := r.SetSkybox(texID); err != nil {
+			return nil, err
+		}
+		r.ReleaseTexture(texID)
+	}
+
+	for _, nd := range d.Nodes {
+		if err := nd.add(sc, r, dir); err != nil {
+			return nil, fmt.Errorf("node %q: %v", nd.Name, err)
+		}
+	}
+	return sc, nil
+}
+
+func (nd *NodeDescription) add(sc *scene.Scene, r *renderer.Renderer, dir string) error {
+	model, err := obj.Load(resolve(dir, nd.Model))
+	if err != nil {
+		return err
+	}
+	program := nd.Program
+	if program == "" {
+		program = "phong"
+	}
+	texture := nd.Texture
+	if texture != "" {
+		texture = resolve(dir, texture)
+	}
+	mat, err := scene.NewMaterial(r, program, texture)
+	if err != nil {
+		return err
+	}
+	if nd.Diffuse != nil {
+		mat.Ambient = *nd.Diffuse
+		mat.Diffuse = *nd.Diffuse
+	}
+	if nd.Specular != nil {
+		mat.Specular = *nd.Specular
+	}
+	if nd.Shininess != nil {
+		mat.Shininess = *nd.Shininess
+	}
+
+	group := sc.NewGroup(nd.Name, nd.Position)
+	rot := mgl32.AnglesToQuat(
+		mgl32.DegToRad(nd.Rotation[0]),
+		mgl32.DegToRad(nd.Rotation[1]),
+		mgl32.DegToRad(nd.Rotation[2]),
+		mgl32.XYZ,
+	)
+	group.SetRotation(rot)
+	if nd.Scale != nil {
+		group.SetScale(*nd.Scale)
+	}
+	for _, mesh := range model.Meshes {
+		n, err := sc.NewNode(r, mesh.Name, true, mesh.Geometry(), mat, mgl32.Vec3{})
+		if err != nil {
+			sc.RemoveNode(r, group)
+			mat.Release(r)
+			return err
+		}
+		group.AddChild(n)
+	}
+	return nil
+}
+
+func resolve(dir, path string) string {
+	path = filepath.FromSlash(path)
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(dir, path)
+}
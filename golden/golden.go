@@ -0,0 +1,118 @@
+package golden
+
+import (
+	"fmt"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-gl/gl/v4.3-core/gl"
+	"github.com/linosgian/goph3d/renderer"
+	"github.com/linosgian/goph3d/window"
+)
+
+// Renders a description in a headless context of its own
+// Every frame advances the scene by the description's DeltaTime, the last one is returned
+func Render(d *Description, dir string) (*image.NRGBA, error) {
+	w, err := window.InitHeadless(d.Width, d.Height, d.Frames)
+	if err != nil {
+		return nil, err
+	}
+	defer w.Destroy()
+
+	r, err := renderer.NewRenderer()
+	if err != nil {
+		return nil, err
+	}
+	sc, err := d.Build(r, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	renderer.CurrentBackend().Enable(gl.DEPTH_TEST)
+	sc.InitLights(r)
+	var img *image.NRGBA
+	for !w.ShouldClose() {
+		sc.DeltaTime = d.DeltaTime
+		sc.LastFrame += d.DeltaTime
+
+		w.Clear()
+		sc.Update(r)
+		sc.Draw(r)
+		img = r.Screenshot()
+		w.EndFrame()
+	}
+	return img, nil
+}
+
+// What checking a description came to
+type Report struct {
+	Name    string
+	Result  Result
+	Updated bool   // The reference was (re)written instead of compared against
+	Diff    string // Diff image written on failure
+}
+
+// Renders the description at path and compares it to the reference next to it,
+// i.e. the same path with a .png extension
+// On a mismatch the rendered image and a diff image are written next to the reference
+// as <name>.got.png and <name>.diff.png, and an error is returned
+// With update the reference is written instead
+func Check(path string, update bool) (*Report, error) {
+	d, err := LoadDescription(path)
+	if err != nil {
+		return nil, err
+	}
+	base := strings.TrimSuffix(path, filepath.Ext(path))
+	rep := &Report{Name: filepath.Base(base)}
+
+	got, err := Render(d, filepath.Dir(path))
+	if err != nil {
+		return rep, err
+	}
+	refPath := base + ".png"
+	if update {
+		rep.Updated = true
+		return rep, renderer.SavePNG(refPath, got)
+	}
+
+	want, err := readPNG(refPath)
+	if err != nil {
+		return rep, fmt.Errorf("%v, run with -update to create it", err)
+	}
+	res, diff, err := Compare(got, want, d.Tolerance)
+	if err != nil {
+		return rep, err
+	}
+	rep.Result = res
+	// Stale outputs of an earlier failure would only confuse
+	os.Remove(base + ".got.png")
+	os.Remove(base + ".diff.png")
+	if res.MismatchRatio() <= d.MaxMismatch {
+		return rep, nil
+	}
+
+	if err := renderer.SavePNG(base+".got.png", got); err != nil {
+		return rep, err
+	}
+	rep.Diff = base + ".diff.png"
+	if err := renderer.SavePNG(rep.Diff, diff); err != nil {
+		return rep, err
+	}
+	return rep, fmt.Errorf("%s: %v", rep.Name, res)
+}
+
+func readPNG(path string) (image.Image, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not open reference: %v", err)
+	}
+	defer f.Close()
+	im, err := png.Decode(f)
+	if err != nil {
+		return nil, fmt.Errorf("could not decode reference %s: %v", path, err)
+	}
+	return im, nil
+}
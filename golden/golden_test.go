@@ -0,0 +1,196 @@
+package golden
+
+import (
+	"flag"
+	"image"
+	"image/color"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/linosgian/goph3d/window"
+)
+
+var update = flag.Bool("update", false, "write the rendered images as the new references")
+
+// Renders every description in res/golden and compares it to its reference
+// Needs -tags egl and a driver to render with, it is skipped otherwise
+func TestScenes(t *testing.T) {
+	// Every GL call has to come from the thread the context was made current on
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	h, err := window.InitHeadless(1, 1, 1)
+	if err != nil {
+		t.Skipf("no headless context: %v", err)
+	}
+	h.Destroy()
+
+	// Shaders are looked up relative to PROJ_PATH, or the working directory without it
+	if os.Getenv("PROJ_PATH") == "" {
+		wd, err := os.Getwd()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chdir(".."); err != nil {
+			t.Fatal(err)
+		}
+		defer os.Chdir(wd)
+	}
+
+	paths, err := filepath.Glob(filepath.Join("res", "golden", "*.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(paths) == 0 {
+		t.Fatal("no scene descriptions found")
+	}
+	for _, path := range paths {
+		rep, err := Check(path, *update)
+		if err != nil {
+			if rep != nil && rep.Diff != "" {
+				t.Errorf("%v, see %s", err, rep.Diff)
+			} else {
+				t.Error(err)
+			}
+			continue
+		}
+		if rep.Updated {
+			t.Logf("%s: reference updated", rep.Name)
+		} else {
+			t.Logf("%s: %v", rep.Name, rep.Result)
+		}
+	}
+}
+
+// A w×h image of a single color
+func uniform(w, h int, c color.NRGBA) *image.NRGBA {
+	im := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for i := 0; i < len(im.Pix); i += 4 {
+		im.Pix[i], im.Pix[i+1], im.Pix[i+2], im.Pix[i+3] = c.R, c.G, c.B, c.A
+	}
+	return im
+}
+
+func TestCompare(t *testing.T) {
+	gray := color.NRGBA{100, 100, 100, 255}
+	want := uniform(4, 2, gray)
+
+	res, diff, err := Compare(uniform(4, 2, gray), want, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res != (Result{Total: 8}) {
+		t.Errorf("identical images: got %+v", res)
+	}
+	// A third of the reference's luma
+	if c := diff.NRGBAAt(0, 0); c != (color.NRGBA{33, 33, 33, 255}) {
+		t.Errorf("matching pixels are %v in the diff", c)
+	}
+
+	got := uniform(4, 2, gray)
+	got.SetNRGBA(1, 0, color.NRGBA{103, 100, 100, 255}) // Within a tolerance of 3
+	got.SetNRGBA(2, 1, color.NRGBA{100, 90, 100, 255})
+	got.SetNRGBA(3, 1, color.NRGBA{100, 100, 100, 200}) // Alpha counts as well
+	res, diff, err = Compare(got, want, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if wantRes := (Result{Mismatched: 2, Total: 8, MaxDiff: 55}); res != wantRes {
+		t.Errorf("got %+v, want %+v", res, wantRes)
+	}
+	red := color.NRGBA{255, 0, 0, 255}
+	for _, p := range []image.Point{{2, 1}, {3, 1}} {
+		if c := diff.NRGBAAt(p.X, p.Y); c != red {
+			t.Errorf("mismatch at %v is %v in the diff, want red", p, c)
+		}
+	}
+	if c := diff.NRGBAAt(1, 0); c == red {
+		t.Error("a pixel within the tolerance is marked in the diff")
+	}
+
+	// Only the size matters, not where the bounds start
+	shifted := image.NewNRGBA(image.Rect(10, 10, 14, 12))
+	copy(shifted.Pix, want.Pix)
+	if res, _, err := Compare(shifted, want, 0); err != nil || res.Mismatched != 0 {
+		t.Errorf("shifted bounds: got %+v, %v", res, err)
+	}
+
+	if _, _, err := Compare(uniform(3, 2, gray), want, 0); err == nil {
+		t.Error("images of different sizes were compared")
+	}
+}
+
+func TestMismatchRatio(t *testing.T) {
+	tests := []struct {
+		res  Result
+		want float64
+	}{
+		{Result{}, 0},
+		{Result{Total: 100}, 0},
+		{Result{Mismatched: 1, Total: 1000}, 0.001},
+		{Result{Mismatched: 50, Total: 50}, 1},
+	}
+	for _, tt := range tests {
+		if got := tt.res.MismatchRatio(); got != tt.want {
+			t.Errorf("%+v: got %v, want %v", tt.res, got, tt.want)
+		}
+	}
+}
+
+func TestLoadDescription(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, text string) string {
+		path := filepath.Join(dir, name)
+		if err := ioutil.WriteFile(path, []byte(text), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	d, err := LoadDescription(write("defaults.json", `{"Nodes": [{"Name": "cube", "Model": "cube.obj"}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.Width != defaultWidth || d.Height != defaultHeight || d.Frames != 1 || d.DeltaTime != defaultDeltaTime {
+		t.Errorf("defaults not filled in: %dx%d, %d frames, %v s", d.Width, d.Height, d.Frames, d.DeltaTime)
+	}
+	if d.Tolerance != 0 || d.MaxMismatch != 0 {
+		t.Errorf("comparisons should be exact by default, got tolerance %d and %v", d.Tolerance, d.MaxMismatch)
+	}
+
+	d, err = LoadDescription(write("set.json", `{"Width": 64, "Height": 32, "Frames": 3, "DeltaTime": 0, "Tolerance": 4, "MaxMismatch": 0.5}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.Width != 64 || d.Height != 32 || d.Frames != 3 || d.DeltaTime != 0 || d.Tolerance != 4 || d.MaxMismatch != 0.5 {
+		t.Errorf("values not read: %+v", d)
+	}
+
+	tests := []struct {
+		name, text, err string
+	}{
+		{"width", `{"Width": 0}`, "invalid size"},
+		{"height", `{"Height": -1}`, "invalid size"},
+		{"frames", `{"Frames": 0}`, "invalid size, frame count"},
+		{"time step", `{"DeltaTime": -0.1}`, "time step"},
+		{"mismatch", `{"MaxMismatch": 1.5}`, "MaxMismatch must be a fraction"},
+		{"model", `{"Nodes": [{"Name": "empty"}]}`, `node "empty" has no model`},
+		{"syntax", `{"Width": }`, "could not parse"},
+		{"type", `{"Width": "wide"}`, "could not parse"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadDescription(write(tt.name+".json", tt.text))
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("got error %v, want one containing %q", err, tt.err)
+			}
+		})
+	}
+	if _, err := LoadDescription(filepath.Join(dir, "missing.json")); err == nil {
+		t.Error("a missing file was read")
+	}
+}
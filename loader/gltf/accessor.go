@@ -0,0 +1,157 @@
+package gltf
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+)
+
+// Accessor component types
+const (
+	componentByte          = 5120
+	componentUnsignedByte  = 5121
+	componentShort         = 5122
+	componentUnsignedShort = 5123
+	componentUnsignedInt   = 5125
+	componentFloat         = 5126
+)
+
+var componentSizes = map[int]int{
+	componentByte:          1,
+	componentUnsignedByte:  1,
+	componentShort:         2,
+	componentUnsignedShort: 2,
+	componentUnsignedInt:   4,
+	componentFloat:         4,
+}
+
+var typeComponents = map[string]int{
+	"SCALAR": 1,
+	"VEC2":   2,
+	"VEC3":   3,
+	"VEC4":   4,
+	"MAT2":   4,
+	"MAT3":   9,
+	"MAT4":   16,
+}
+
+// Locates the elements of an accessor
+// Returns the backing bytes, the byte stride between elements,
+// the number of components per element and the size of one component
+func (a *asset) accessorLayout(idx int) (*accessor, []byte, int, int, int, error) {
+	if idx < 0 || idx >= len(a.doc.Accessors) {
+		return nil, nil, 0, 0, 0, fmt.Errorf("accessor %d out of range", idx)
+	}
+	acc := &a.doc.Accessors[idx]
+	if acc.Sparse != nil {
+		return nil, nil, 0, 0, 0, fmt.Errorf("accessor %d: sparse accessors are not supported", idx)
+	}
+	comps, ok := typeComponents[acc.Type]
+	if !ok {
+		return nil, nil, 0, 0, 0, fmt.Errorf("accessor %d: unknown type %q", idx, acc.Type)
+	}
+	compSize, ok := componentSizes[acc.ComponentType]
+	if !ok {
+		return nil, nil, 0, 0, 0, fmt.Errorf("accessor %d: unknown component type %d", idx, acc.ComponentType)
+	}
+	elemSize := comps * compSize
+	if acc.Count < 0 {
+		return nil, nil, 0, 0, 0, fmt.Errorf("accessor %d: negative count %d", idx, acc.Count)
+	}
+	if acc.ByteOffset < 0 {
+		return nil, nil, 0, 0, 0, fmt.Errorf("accessor %d: negative byte offset %d", idx, acc.ByteOffset)
+	}
+
+	// An accessor without a buffer view reads as zeros
+	if acc.BufferView == nil {
+		return acc, make([]byte, acc.Count*elemSize), elemSize, comps, compSize, nil
+	}
+
+	data, bv, err := a.viewData(*acc.BufferView)
+	if err != nil {
+		return nil, nil, 0, 0, 0, fmt.Errorf("accessor %d: %v", idx, err)
+	}
+	stride := elemSize
+	if bv.ByteStride != 0 {
+		stride = bv.ByteStride
+	}
+	if stride < elemSize {
+		return nil, nil, 0, 0, 0, fmt.Errorf("accessor %d: byte stride %d is smaller than an element (%d bytes)", idx, stride, elemSize)
+	}
+	if acc.ByteOffset > len(data) || acc.Count > 0 && acc.ByteOffset+(acc.Count-1)*stride+elemSize > len(data) {
+		return nil, nil, 0, 0, 0, fmt.Errorf("accessor %d exceeds its buffer view", idx)
+	}
+	return acc, data[acc.ByteOffset:], stride, comps, compSize, nil
+}
+
+// Reads an accessor as floats, normalizing integer components if asked to
+// Returns the values and the number of components per element
+func (a *asset) readFloats(idx int) ([]float32, int, error) {
+	acc, data, stride, comps, compSize, err := a.accessorLayout(idx)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	out := make([]float32, 0, acc.Count*comps)
+	for i := 0; i < acc.Count; i++ {
+		for c := 0; c < comps; c++ {
+			b := data[i*stride+c*compSize:]
+			var v float32
+			switch acc.ComponentType {
+			case componentFloat:
+				v = math.Float32frombits(binary.LittleEndian.Uint32(b))
+			case componentByte:
+				v = float32(int8(b[0]))
+				if acc.Normalized {
+					v = float32(math.Max(float64(v)/127, -1))
+				}
+			case componentUnsignedByte:
+				v = float32(b[0])
+				if acc.Normalized {
+					v /= 255
+				}
+			case componentShort:
+				v = float32(int16(binary.LittleEndian.Uint16(b)))
+				if acc.Normalized {
+					v = float32(math.Max(float64(v)/32767, -1))
+				}
+			case componentUnsignedShort:
+				v = float32(binary.LittleEndian.Uint16(b))
+				if acc.Normalized {
+					v /= 65535
+				}
+			case componentUnsignedInt:
+				v = float32(binary.LittleEndian.Uint32(b))
+			}
+			out = append(out, v)
+		}
+	}
+	return out, comps, nil
+}
+
+// Reads an index accessor
+func (a *asset) readIndices(idx int) ([]uint32, error) {
+	acc, data, stride, comps, _, err := a.accessorLayout(idx)
+	if err != nil {
+		return nil, err
+	}
+	if comps != 1 {
+		return nil, fmt.Errorf("accessor %d: indices must be scalars", idx)
+	}
+
+	out := make([]uint32, acc.Count)
+	for i := range out {
+		b := data[i*stride:]
+		switch acc.ComponentType {
+		case componentUnsignedByte:
+			out[i] = uint32(b[0])
+		case componentUnsignedShort:
+			out[i] = uint32(binary.LittleEndian.Uint16(b))
+		case componentUnsignedInt:
+			out[i] = binary.LittleEndian.Uint32(b)
+		default:
+			return nil, fmt.Errorf("accessor %d: invalid index component type %d", idx, acc.ComponentType)
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,222 @@
+package gltf
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"io/ioutil"
+	"math"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func intp(i int) *int { return &i }
+
+// Little-endian floats, as stored in glTF buffers
+func floatBytes(fs ...float32) []byte {
+	b := make([]byte, 4*len(fs))
+	for i, f := range fs {
+		binary.LittleEndian.PutUint32(b[4*i:], math.Float32bits(f))
+	}
+	return b
+}
+
+// An asset with a single 24 byte buffer and a view over all of it
+func testAsset(stride int, accs ...accessor) *asset {
+	a := &asset{buffers: [][]byte{floatBytes(1, 2, 3, 4, 5, 6)}}
+	a.doc.BufferViews = []bufferView{{Buffer: 0, ByteLength: 24, ByteStride: stride}}
+	a.doc.Accessors = accs
+	return a
+}
+
+func TestReadFloats(t *testing.T) {
+	tests := []struct {
+		name   string
+		stride int
+		acc    accessor
+		want   []float32
+		comps  int
+	}{
+		{"vec3", 0, accessor{BufferView: intp(0), ComponentType: componentFloat, Count: 2, Type: "VEC3"}, []float32{1, 2, 3, 4, 5, 6}, 3},
+		{"offset", 0, accessor{BufferView: intp(0), ByteOffset: 8, ComponentType: componentFloat, Count: 2, Type: "VEC2"}, []float32{3, 4, 5, 6}, 2},
+		{"interleaved", 12, accessor{BufferView: intp(0), ByteOffset: 4, ComponentType: componentFloat, Count: 2, Type: "SCALAR"}, []float32{2, 5}, 1},
+		{"empty", 0, accessor{BufferView: intp(0), ByteOffset: 24, ComponentType: componentFloat, Count: 0, Type: "VEC3"}, []float32{}, 3},
+		{"no buffer view", 0, accessor{ComponentType: componentFloat, Count: 2, Type: "VEC2"}, []float32{0, 0, 0, 0}, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, comps, err := testAsset(tt.stride, tt.acc).readFloats(0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) || comps != tt.comps {
+				t.Errorf("got %v (%d components), want %v (%d components)", got, comps, tt.want, tt.comps)
+			}
+		})
+	}
+}
+
+func TestAccessorLayoutErrors(t *testing.T) {
+	float3 := func(f func(*accessor)) accessor {
+		acc := accessor{BufferView: intp(0), ComponentType: componentFloat, Count: 2, Type: "VEC3"}
+		f(&acc)
+		return acc
+	}
+	tests := []struct {
+		name   string
+		stride int
+		acc    accessor
+		err    string
+	}{
+		{"negative count", 0, float3(func(a *accessor) { a.Count = -1 }), "accessor 0: negative count -1"},
+		{"negative count without view", 0, float3(func(a *accessor) { a.Count, a.BufferView = -1, nil }), "accessor 0: negative count -1"},
+		{"negative offset", 0, float3(func(a *accessor) { a.ByteOffset = -4 }), "accessor 0: negative byte offset -4"},
+		{"offset past the view", 0, float3(func(a *accessor) { a.ByteOffset, a.Count = 28, 0 }), "accessor 0 exceeds its buffer view"},
+		{"too many elements", 0, float3(func(a *accessor) { a.Count = 3 }), "accessor 0 exceeds its buffer view"},
+		{"offset overruns", 0, float3(func(a *accessor) { a.ByteOffset = 4 }), "accessor 0 exceeds its buffer view"},
+		{"negative stride", -12, float3(func(*accessor) {}), "accessor 0: byte stride -12 is smaller than an element (12 bytes)"},
+		{"short stride", 8, float3(func(*accessor) {}), "accessor 0: byte stride 8 is smaller than an element (12 bytes)"},
+		{"missing view", 0, float3(func(a *accessor) { a.BufferView = intp(1) }), "accessor 0: buffer view 1 out of range"},
+		{"sparse", 0, float3(func(a *accessor) { a.Sparse = json.RawMessage("{}") }), "accessor 0: sparse accessors are not supported"},
+		{"unknown type", 0, float3(func(a *accessor) { a.Type = "VEC5" }), `accessor 0: unknown type "VEC5"`},
+		{"unknown component type", 0, float3(func(a *accessor) { a.ComponentType = 5130 }), "accessor 0: unknown component type 5130"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := testAsset(tt.stride, tt.acc).readFloats(0)
+			if err == nil || err.Error() != tt.err {
+				t.Errorf("got error %v, want %q", err, tt.err)
+			}
+		})
+	}
+
+	if _, _, err := testAsset(0).readFloats(0); err == nil || err.Error() != "accessor 0 out of range" {
+		t.Errorf("got error %v for a missing accessor", err)
+	}
+	a := testAsset(0)
+	a.doc.BufferViews[0].ByteLength = -4
+	if _, _, err := a.viewData(0); err == nil {
+		t.Error("a negative view length was accepted")
+	}
+}
+
+func TestReadIndices(t *testing.T) {
+	a := &asset{buffers: [][]byte{{0, 1, 2, 0, 3, 0, 0, 0, 1, 0}}}
+	a.doc.BufferViews = []bufferView{{Buffer: 0, ByteLength: 10}}
+	a.doc.Accessors = []accessor{
+		{BufferView: intp(0), ComponentType: componentUnsignedByte, Count: 3, Type: "SCALAR"},
+		{BufferView: intp(0), ByteOffset: 2, ComponentType: componentUnsignedShort, Count: 2, Type: "SCALAR"},
+		{BufferView: intp(0), ByteOffset: 4, ComponentType: componentUnsignedInt, Count: 1, Type: "SCALAR"},
+		{BufferView: intp(0), ComponentType: componentUnsignedByte, Count: 1, Type: "VEC2"},
+		{BufferView: intp(0), ComponentType: componentFloat, Count: 1, Type: "SCALAR"},
+	}
+	for i, want := range [][]uint32{{0, 1, 2}, {2, 3}, {3}} {
+		got, err := a.readIndices(i)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("accessor %d: got %v, want %v", i, got, want)
+		}
+	}
+	if _, err := a.readIndices(3); err == nil {
+		t.Error("vector indices were accepted")
+	}
+	if _, err := a.readIndices(4); err == nil {
+		t.Error("float indices were accepted")
+	}
+}
+
+// Builds a GLB container from its JSON and binary chunks
+func glb(js string, bin []byte) []byte {
+	pad := func(b []byte, c byte) []byte {
+		for len(b)%4 != 0 {
+			b = append(b, c)
+		}
+		return b
+	}
+	chunk := func(typ uint32, data []byte) []byte {
+		h := make([]byte, 8)
+		binary.LittleEndian.PutUint32(h, uint32(len(data)))
+		binary.LittleEndian.PutUint32(h[4:], typ)
+		return append(h, data...)
+	}
+
+	body := chunk(glbChunkJSON, pad([]byte(js), ' '))
+	if bin != nil {
+		body = append(body, chunk(glbChunkBIN, pad(bin, 0))...)
+	}
+	h := make([]byte, 12)
+	binary.LittleEndian.PutUint32(h, glbMagic)
+	binary.LittleEndian.PutUint32(h[4:], 2)
+	binary.LittleEndian.PutUint32(h[8:], uint32(12+len(body)))
+	return append(h, body...)
+}
+
+const glbJSON = `{
+	"asset": {"version": "2.0"},
+	"buffers": [{"byteLength": 24}],
+	"bufferViews": [{"buffer": 0, "byteLength": 24}],
+	"accessors": [{"bufferView": 0, "componentType": 5126, "count": 2, "type": "VEC3"}]
+}`
+
+func TestReadGLB(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tri.glb")
+	if err := ioutil.WriteFile(path, glb(glbJSON, floatBytes(1, 2, 3, 4, 5, 6)), 0644); err != nil {
+		t.Fatal(err)
+	}
+	a, err := readAsset(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, _, err := a.readFloats(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []float32{1, 2, 3, 4, 5, 6}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestSplitGLB(t *testing.T) {
+	valid := glb(glbJSON, floatBytes(1, 2))
+	js, bin, err := splitGLB(valid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.TrimSpace(string(js)) != glbJSON || !reflect.DeepEqual(bin, floatBytes(1, 2)) {
+		t.Errorf("chunks were not split apart: %q, %v", js, bin)
+	}
+
+	version := append([]byte(nil), valid...)
+	binary.LittleEndian.PutUint32(version[4:], 1)
+	noJSON := glb("", nil)[:12]
+	binary.LittleEndian.PutUint32(noJSON[8:], 12)
+
+	tests := []struct {
+		name string
+		data []byte
+		err  string
+	}{
+		{"version", version, "unsupported glb version 1"},
+		{"truncated file", valid[:len(valid)-4], "truncated glb file"},
+		{"truncated chunk", glbTruncatedChunk(), "truncated glb chunk"},
+		{"no json", noJSON, "glb file has no json chunk"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := splitGLB(tt.data)
+			if err == nil || err.Error() != tt.err {
+				t.Errorf("got error %v, want %q", err, tt.err)
+			}
+		})
+	}
+}
+
+// A GLB whose only chunk claims more bytes than the file holds
+func glbTruncatedChunk() []byte {
+	data := glb("{}", nil)
+	binary.LittleEndian.PutUint32(data[12:], 64)
+	return data
+}
@@ -0,0 +1,305 @@
+// Package gltf imports glTF 2.0 assets (.gltf and .glb) into a scene.Scene
+package gltf
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"path/filepath"
+	"strings"
+)
+
+// The subset of the glTF 2.0 JSON schema the importer reads
+type document struct {
+	Asset struct {
+		Version string `json:"version"`
+	} `json:"asset"`
+	Scene       *int         `json:"scene"`
+	Scenes      []gScene     `json:"scenes"`
+	Nodes       []gNode      `json:"nodes"`
+	Meshes      []gMesh      `json:"meshes"`
+	Accessors   []accessor   `json:"accessors"`
+	BufferViews []bufferView `json:"bufferViews"`
+	Buffers     []buffer     `json:"buffers"`
+	Materials   []material   `json:"materials"`
+	Textures    []texture    `json:"textures"`
+	Samplers    []sampler    `json:"samplers"`
+	Images      []gImage     `json:"images"`
+	Cameras     []camera     `json:"cameras"`
+	Extensions  struct {
+		Lights *struct {
+			Lights []light `json:"lights"`
+		} `json:"KHR_lights_punctual"`
+	} `json:"extensions"`
+	ExtensionsRequired []string `json:"extensionsRequired"`
+}
+
+type gScene struct {
+	Name  string `json:"name"`
+	Nodes []int  `json:"nodes"`
+}
+
+type gNode struct {
+	Name        string       `json:"name"`
+	Children    []int        `json:"children"`
+	Mesh        *int         `json:"mesh"`
+	Camera      *int         `json:"camera"`
+	Matrix      *[16]float32 `json:"matrix"`
+	Translation *[3]float32  `json:"translation"`
+	Rotation    *[4]float32  `json:"rotation"` // x, y, z, w
+	Scale       *[3]float32  `json:"scale"`
+	Extensions  struct {
+		Light *struct {
+			Light int `json:"light"`
+		} `json:"KHR_lights_punctual"`
+	} `json:"extensions"`
+}
+
+type gMesh struct {
+	Name       string      `json:"name"`
+	Primitives []primitive `json:"primitives"`
+}
+
+type primitive struct {
+	Attributes map[string]int `json:"attributes"`
+	Indices    *int           `json:"indices"`
+	Material   *int           `json:"material"`
+	Mode       *int           `json:"mode"`
+}
+
+type accessor struct {
+	BufferView    *int            `json:"bufferView"`
+	ByteOffset    int             `json:"byteOffset"`
+	ComponentType int             `json:"componentType"`
+	Normalized    bool            `json:"normalized"`
+	Count         int             `json:"count"`
+	Type          string          `json:"type"`
+	Sparse        json.RawMessage `json:"sparse"`
+}
+
+type bufferView struct {
+	Buffer     int `json:"buffer"`
+	ByteOffset int `json:"byteOffset"`
+	ByteLength int `json:"byteLength"`
+	ByteStride int `json:"byteStride"`
+}
+
+type buffer struct {
+	URI        string `json:"uri"`
+	ByteLength int    `json:"byteLength"`
+}
+
+type textureInfo struct {
+	Index int `json:"index"`
+}
+
+type material struct {
+	Name                 string `json:"name"`
+	PBRMetallicRoughness struct {
+		BaseColorFactor          *[4]float32  `json:"baseColorFactor"`
+		BaseColorTexture         *textureInfo `json:"baseColorTexture"`
+		MetallicFactor           *float32     `json:"metallicFactor"`
+		RoughnessFactor          *float32     `json:"roughnessFactor"`
+		MetallicRoughnessTexture *textureInfo `json:"metallicRoughnessTexture"`
+	} `json:"pbrMetallicRoughness"`
+	NormalTexture   *textureInfo `json:"normalTexture"`
+	EmissiveTexture *textureInfo `json:"emissiveTexture"`
+	EmissiveFactor  [3]float32   `json:"emissiveFactor"`
+	AlphaMode       string       `json:"alphaMode"`
+	DoubleSided     bool         `json:"doubleSided"`
+}
+
+type texture struct {
+	Source  *int `json:"source"`
+	Sampler *int `json:"sampler"`
+}
+
+// Filters and wrap modes are GL enums
+type sampler struct {
+	MagFilter *int32 `json:"magFilter"`
+	MinFilter *int32 `json:"minFilter"`
+	WrapS     *int32 `json:"wrapS"`
+	WrapT     *int32 `json:"wrapT"`
+}
+
+type gImage struct {
+	URI        string `json:"uri"`
+	MimeType   string `json:"mimeType"`
+	BufferView *int   `json:"bufferView"`
+}
+
+type camera struct {
+	Type        string `json:"type"`
+	Perspective *struct {
+		AspectRatio *float32 `json:"aspectRatio"`
+		YFov        float32  `json:"yfov"`
+		ZNear       float32  `json:"znear"`
+		ZFar        *float32 `json:"zfar"`
+	} `json:"perspective"`
+}
+
+type light struct {
+	Name      string      `json:"name"`
+	Type      string      `json:"type"`
+	Color     *[3]float32 `json:"color"`
+	Intensity *float32    `json:"intensity"`
+	Range     *float32    `json:"range"`
+}
+
+// GLB container constants
+const (
+	glbMagic     = 0x46546C67 // "glTF"
+	glbChunkJSON = 0x4E4F534A // "JSON"
+	glbChunkBIN  = 0x004E4942 // "BIN\0"
+)
+
+// A parsed asset: the JSON document and the contents of all its buffers
+type asset struct {
+	doc     document
+	dir     string // Directory external resources are resolved against
+	buffers [][]byte
+}
+
+// Reads a .gltf or .glb file and all the buffers it references
+func readAsset(path string) (*asset, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not read gltf file: %v", err)
+	}
+	a := &asset{dir: filepath.Dir(path)}
+
+	jsonChunk, binChunk := data, []byte(nil)
+	if len(data) >= 12 && binary.LittleEndian.Uint32(data) == glbMagic {
+		if jsonChunk, binChunk, err = splitGLB(data); err != nil {
+			return nil, err
+		}
+	}
+	if err := json.Unmarshal(jsonChunk, &a.doc); err != nil {
+		return nil, fmt.Errorf("could not parse gltf json: %v", err)
+	}
+	if !strings.HasPrefix(a.doc.Asset.Version, "2.") {
+		return nil, fmt.Errorf("unsupported gltf version %q", a.doc.Asset.Version)
+	}
+	for _, ext := range a.doc.ExtensionsRequired {
+		if ext != "KHR_lights_punctual" {
+			return nil, fmt.Errorf("unsupported required gltf extension %q", ext)
+		}
+	}
+
+	a.buffers = make([][]byte, len(a.doc.Buffers))
+	for i, b := range a.doc.Buffers {
+		var buf []byte
+		switch {
+		case b.URI == "" && i == 0 && binChunk != nil:
+			buf = binChunk
+		case b.URI == "":
+			return nil, fmt.Errorf("buffer %d has no data", i)
+		default:
+			if buf, err = a.readURI(b.URI); err != nil {
+				return nil, fmt.Errorf("buffer %d: %v", i, err)
+			}
+		}
+		if len(buf) < b.ByteLength {
+			return nil, fmt.Errorf("buffer %d holds %d bytes, expected %d", i, len(buf), b.ByteLength)
+		}
+		a.buffers[i] = buf
+	}
+	return a, nil
+}
+
+// Splits a GLB container into its JSON and (optional) binary chunk
+func splitGLB(data []byte) ([]byte, []byte, error) {
+	if version := binary.LittleEndian.Uint32(data[4:]); version != 2 {
+		return nil, nil, fmt.Errorf("unsupported glb version %d", version)
+	}
+	length := int(binary.LittleEndian.Uint32(data[8:]))
+	if length > len(data) {
+		return nil, nil, fmt.Errorf("truncated glb file")
+	}
+
+	var jsonChunk, binChunk []byte
+	for off := 12; off+8 <= length; {
+		chunkLen := int(binary.LittleEndian.Uint32(data[off:]))
+		chunkType := binary.LittleEndian.Uint32(data[off+4:])
+		off += 8
+		if off+chunkLen > length {
+			return nil, nil, fmt.Errorf("truncated glb chunk")
+		}
+		chunk := data[off : off+chunkLen]
+		switch {
+		case chunkType == glbChunkJSON && jsonChunk == nil:
+			jsonChunk = chunk
+		case chunkType == glbChunkBIN && binChunk == nil:
+			binChunk = chunk
+		}
+		// Unknown chunks are skipped
+		off += chunkLen
+	}
+	if jsonChunk == nil {
+		return nil, nil, fmt.Errorf("glb file has no json chunk")
+	}
+	return jsonChunk, binChunk, nil
+}
+
+// Reads a data URI or a file relative to the asset
+func (a *asset) readURI(uri string) ([]byte, error) {
+	if strings.HasPrefix(uri, "data:") {
+		i := strings.IndexByte(uri, ',')
+		if i < 0 || !strings.HasSuffix(uri[:i], ";base64") {
+			return nil, fmt.Errorf("unsupported data uri")
+		}
+		return base64.StdEncoding.DecodeString(uri[i+1:])
+	}
+	path, err := a.resolve(uri)
+	if err != nil {
+		return nil, err
+	}
+	return ioutil.ReadFile(path)
+}
+
+// Turns a relative, percent-encoded URI into a file path
+func (a *asset) resolve(uri string) (string, error) {
+	p, err := url.PathUnescape(uri)
+	if err != nil {
+		return "", fmt.Errorf("invalid uri %q: %v", uri, err)
+	}
+	return filepath.Join(a.dir, filepath.FromSlash(p)), nil
+}
+
+// Returns the bytes of a buffer view
+func (a *asset) viewData(idx int) ([]byte, *bufferView, error) {
+	if idx < 0 || idx >= len(a.doc.BufferViews) {
+		return nil, nil, fmt.Errorf("buffer view %d out of range", idx)
+	}
+	bv := &a.doc.BufferViews[idx]
+	if bv.Buffer < 0 || bv.Buffer >= len(a.buffers) {
+		return nil, nil, fmt.Errorf("buffer %d out of range", bv.Buffer)
+	}
+	buf := a.buffers[bv.Buffer]
+	end := bv.ByteOffset + bv.ByteLength
+	if bv.ByteOffset < 0 || bv.ByteLength < 0 || end > len(buf) {
+		return nil, nil, fmt.Errorf("buffer view %d exceeds its buffer", idx)
+	}
+	return buf[bv.ByteOffset:end], bv, nil
+}
+
+// Returns the encoded bytes of an image stored in a buffer view or a data URI
+// External images are loaded by path instead, see importer.texture
+func (a *asset) imageData(img *gImage) (*bytes.Reader, error) {
+	if img.BufferView != nil {
+		data, _, err := a.viewData(*img.BufferView)
+		if err != nil {
+			return nil, err
+		}
+		return bytes.NewReader(data), nil
+	}
+	data, err := a.readURI(img.URI)
+	if err != nil {
+		return nil, err
+	}
+	return bytes.NewReader(data), nil
+}
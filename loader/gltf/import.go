@@ -0,0 +1,551 @@
+package gltf
+
+import (
+	"fmt"
+	"image"
+	"log"
+	"math"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/linosgian/goph3d/renderer"
+	"github.com/linosgian/goph3d/scene"
+)
+
+// Primitive mode for triangle lists, the only one the renderer draws
+const modeTriangles = 4
+
+// Sampler min filters that don't use mipmaps
+const (
+	glNearest = 9728
+	glLinear  = 9729
+)
+
+type importer struct {
+	*asset
+	sc        *scene.Scene
+	r         *renderer.Renderer
+	program   string
+	programID int
+
+	textures  map[int]int              // glTF texture index to internal texture ID, holding a reference of its own
+	meshes    map[int][]*renderer.Mesh // Uploaded primitives of every glTF mesh
+	materials map[int]*scene.Material
+	fallback  *scene.Material // For primitives without a material
+	cameraSet bool
+
+	lights   []*scene.PointLight // Added to the scene so far
+	dirLight scene.DirLight      // The scene's before the import
+}
+
+// Imports a .gltf or .glb file into the scene
+// Meshes are uploaded through the renderer and drawn by programName (e.g. "phong").
+// The first camera found drives the scene camera, point and spot lights become
+// PointLights (spot cones are not supported) and a directional light replaces
+// the scene's DirLight
+// The materials belong to the caller, who releases them once the nodes are removed.
+// On error nothing of the file is left in the scene
+// Returns the node all imported nodes descend from
+func Load(path string, sc *scene.Scene, r *renderer.Renderer, programName string) (*scene.Node, error) {
+	a, err := readAsset(path)
+	if err != nil {
+		return nil, err
+	}
+	programID, err := r.GetProgram(programName)
+	if err != nil {
+		return nil, err
+	}
+	imp := &importer{
+		asset:     a,
+		sc:        sc,
+		r:         r,
+		program:   programName,
+		programID: programID,
+		textures:  make(map[int]int),
+		meshes:    make(map[int][]*renderer.Mesh),
+		materials: make(map[int]*scene.Material),
+	}
+
+	imp.dirLight = sc.DirLight
+
+	root := sc.NewGroup(filepath.Base(path), mgl32.Vec3{})
+	for _, idx := range imp.rootNodes() {
+		if err = imp.importNode(idx, root, make(map[int]bool)); err != nil {
+			break
+		}
+	}
+	// The materials hold their own references
+	for _, texID := range imp.textures {
+		r.ReleaseTexture(texID)
+	}
+	if err != nil {
+		imp.discard(root)
+		return nil, err
+	}
+	return root, nil
+}
+
+// Takes everything a failed import added back out of the scene
+// Meshes and textures are deleted along with their last reference
+func (imp *importer) discard(root *scene.Node) {
+	imp.sc.RemoveNode(imp.r, root)
+	for _, m := range imp.materials {
+		m.Release(imp.r)
+	}
+	if imp.fallback != nil {
+		imp.fallback.Release(imp.r)
+	}
+	for _, l := range imp.lights {
+		imp.sc.RemovePointLight(l)
+	}
+	imp.sc.DirLight = imp.dirLight
+}
+
+// Returns the nodes of the default scene, or every node
+// that is nobody's child if the file has no scenes
+func (imp *importer) rootNodes() []int {
+	doc := &imp.doc
+	if len(doc.Scenes) > 0 {
+		s := 0
+		if doc.Scene != nil && *doc.Scene < len(doc.Scenes) {
+			s = *doc.Scene
+		}
+		return doc.Scenes[s].Nodes
+	}
+
+	isChild := make(map[int]bool)
+	for _, n := range doc.Nodes {
+		for _, c := range n.Children {
+			isChild[c] = true
+		}
+	}
+	roots := make([]int, 0)
+	for i := range doc.Nodes {
+		if !isChild[i] {
+			roots = append(roots, i)
+		}
+	}
+	return roots
+}
+
+func (imp *importer) importNode(idx int, parent *scene.Node, visiting map[int]bool) error {
+	if idx < 0 || idx >= len(imp.doc.Nodes) {
+		return fmt.Errorf("node %d out of range", idx)
+	}
+	if visiting[idx] {
+		return fmt.Errorf("node %d is its own ancestor", idx)
+	}
+	visiting[idx] = true
+	defer delete(visiting, idx)
+
+	gn := &imp.doc.Nodes[idx]
+	name := gn.Name
+	if name == "" {
+		name = fmt.Sprintf("node%d", idx)
+	}
+
+	n := imp.sc.NewGroup(name, mgl32.Vec3{})
+	parent.AddChild(n)
+	setTransform(n, gn)
+
+	if gn.Mesh != nil {
+		if err := imp.importMesh(*gn.Mesh, n); err != nil {
+			return err
+		}
+	}
+	if gn.Camera != nil {
+		imp.importCamera(*gn.Camera, n)
+	}
+	if gn.Extensions.Light != nil {
+		if err := imp.importLight(gn.Extensions.Light.Light, n); err != nil {
+			return err
+		}
+	}
+
+	for _, c := range gn.Children {
+		if err := imp.importNode(c, n, visiting); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func setTransform(n *scene.Node, gn *gNode) {
+	if gn.Matrix != nil {
+		// glTF matrices are column-major, just like mgl32's
+		n.SetModelMatrix(mgl32.Mat4(*gn.Matrix))
+		return
+	}
+	t, q, s := mgl32.Vec3{}, mgl32.QuatIdent(), mgl32.Vec3{1, 1, 1}
+	if gn.Translation != nil {
+		t = mgl32.Vec3(*gn.Translation)
+	}
+	if gn.Rotation != nil {
+		r := gn.Rotation
+		q = mgl32.Quat{W: r[3], V: mgl32.Vec3{r[0], r[1], r[2]}}
+	}
+	if gn.Scale != nil {
+		s = mgl32.Vec3(*gn.Scale)
+	}
+	n.SetTRS(t, q, s)
+}
+
+// Adds one renderable child node per primitive of the mesh
+// A mesh used by several nodes is only uploaded once
+func (imp *importer) importMesh(idx int, parent *scene.Node) error {
+	if idx < 0 || idx >= len(imp.doc.Meshes) {
+		return fmt.Errorf("mesh %d out of range", idx)
+	}
+	m := &imp.doc.Meshes[idx]
+	uploaded, shared := imp.meshes[idx]
+	for i := range m.Primitives {
+		p := &m.Primitives[i]
+
+		var geom scene.Geometry
+		if shared {
+			geom = uploaded[i]
+		} else {
+			vertices, indices, err := imp.primitiveData(p)
+			if err != nil {
+				return fmt.Errorf("mesh %d primitive %d: %v", idx, i, err)
+			}
+			geom = scene.RawGeometry{Vertices: vertices, Indices: indices}
+		}
+		mat, err := imp.material(p.Material)
+		if err != nil {
+			return err
+		}
+
+		name := fmt.Sprintf("%s/%d", m.Name, i)
+		pn, err := imp.sc.NewNode(imp.r, name, true, geom, mat, mgl32.Vec3{})
+		if err != nil {
+			return err
+		}
+		parent.AddChild(pn)
+		if !shared {
+			imp.meshes[idx] = append(imp.meshes[idx], pn.Mesh)
+		}
+	}
+	return nil
+}
+
+// Interleaves a primitive's attributes into the default position/texture/normal layout
+func (imp *importer) primitiveData(p *primitive) ([]float32, []uint32, error) {
+	if p.Mode != nil && *p.Mode != modeTriangles {
+		return nil, nil, fmt.Errorf("unsupported primitive mode %d", *p.Mode)
+	}
+
+	posIdx, ok := p.Attributes["POSITION"]
+	if !ok {
+		return nil, nil, fmt.Errorf("no POSITION attribute")
+	}
+	pos, comps, err := imp.readFloats(posIdx)
+	if err != nil {
+		return nil, nil, err
+	}
+	if comps != 3 {
+		return nil, nil, fmt.Errorf("POSITION must be a VEC3")
+	}
+	count := len(pos) / 3
+
+	var uvs, norms []float32
+	if idx, ok := p.Attributes["TEXCOORD_0"]; ok {
+		if uvs, comps, err = imp.readFloats(idx); err != nil {
+			return nil, nil, err
+		}
+		if comps != 2 || len(uvs)/2 != count {
+			return nil, nil, fmt.Errorf("TEXCOORD_0 does not match POSITION")
+		}
+	}
+	if idx, ok := p.Attributes["NORMAL"]; ok {
+		if norms, comps, err = imp.readFloats(idx); err != nil {
+			return nil, nil, err
+		}
+		if comps != 3 || len(norms)/3 != count {
+			return nil, nil, fmt.Errorf("NORMAL does not match POSITION")
+		}
+	}
+
+	var indices []uint32
+	if p.Indices != nil {
+		if indices, err = imp.readIndices(*p.Indices); err != nil {
+			return nil, nil, err
+		}
+		for _, i := range indices {
+			if int(i) >= count {
+				return nil, nil, fmt.Errorf("index %d out of range for %d vertices", i, count)
+			}
+		}
+	}
+
+	vertex := func(i uint32) []float32 {
+		v := []float32{pos[i*3], pos[i*3+1], pos[i*3+2], 0, 0, 0, 0, 0}
+		if uvs != nil {
+			// Textures are uploaded rotated by 180 degrees (see renderer.ReadImageFile)
+			// while glTF puts the UV origin at the top left of the image
+			v[3], v[4] = 1-uvs[i*2], 1-uvs[i*2+1]
+		}
+		if norms != nil {
+			v[5], v[6], v[7] = norms[i*3], norms[i*3+1], norms[i*3+2]
+		}
+		return v
+	}
+
+	if norms != nil {
+		vertices := make([]float32, 0, count*8)
+		for i := 0; i < count; i++ {
+			vertices = append(vertices, vertex(uint32(i))...)
+		}
+		return vertices, indices, nil
+	}
+
+	// Without normals the spec asks for flat shading, so every triangle
+	// gets its own vertices carrying the face normal
+	if indices == nil {
+		indices = make([]uint32, count)
+		for i := range indices {
+			indices[i] = uint32(i)
+		}
+	}
+	vertices := make([]float32, 0, len(indices)*8)
+	for t := 0; t+2 < len(indices); t += 3 {
+		tri := [3][]float32{vertex(indices[t]), vertex(indices[t+1]), vertex(indices[t+2])}
+		a := mgl32.Vec3{tri[0][0], tri[0][1], tri[0][2]}
+		b := mgl32.Vec3{tri[1][0], tri[1][1], tri[1][2]}
+		c := mgl32.Vec3{tri[2][0], tri[2][1], tri[2][2]}
+		n := b.Sub(a).Cross(c.Sub(a))
+		if n.Len() > 0 {
+			n = n.Normalize()
+		}
+		for _, v := range tri {
+			v[5], v[6], v[7] = n[0], n[1], n[2]
+			vertices = append(vertices, v...)
+		}
+	}
+	return vertices, nil, nil
+}
+
+// Converts a metallic-roughness material to the renderer's Phong model
+// Metallic-roughness textures have no Phong equivalent and are ignored
+func (imp *importer) material(idx *int) (*scene.Material, error) {
+	if idx == nil {
+		if imp.fallback == nil {
+			m, err := scene.NewMaterial(imp.r, imp.program, "")
+			if err != nil {
+				return nil, err
+			}
+			imp.fallback = m
+		}
+		return imp.fallback, nil
+	}
+	if m, ok := imp.materials[*idx]; ok {
+		return m, nil
+	}
+	if *idx < 0 || *idx >= len(imp.doc.Materials) {
+		return nil, fmt.Errorf("material %d out of range", *idx)
+	}
+	gm := &imp.doc.Materials[*idx]
+	pbr := &gm.PBRMetallicRoughness
+
+	m, err := scene.NewMaterial(imp.r, imp.program, "")
+	if err != nil {
+		return nil, err
+	}
+	m.Name = gm.Name
+
+	base := mgl32.Vec4{1, 1, 1, 1}
+	if pbr.BaseColorFactor != nil {
+		base = mgl32.Vec4(*pbr.BaseColorFactor)
+	}
+	metallic, roughness := float32(1), float32(1)
+	if pbr.MetallicFactor != nil {
+		metallic = *pbr.MetallicFactor
+	}
+	if pbr.RoughnessFactor != nil {
+		roughness = *pbr.RoughnessFactor
+	}
+
+	m.Ambient = base.Vec3()
+	m.Diffuse = base.Vec3()
+	// Dielectrics reflect about 4% of the light, metals reflect their own color
+	m.Specular = mgl32.Vec3{0.04, 0.04, 0.04}.Mul(1 - metallic).Add(base.Vec3().Mul(metallic))
+	// Blinn-Phong exponent matching the GGX distribution, alpha = roughness^2
+	alpha := roughness * roughness
+	m.Shininess = float32(math.Min(math.Max(2/math.Max(float64(alpha*alpha), 1e-4)-2, 1), 256))
+	m.Emissive = mgl32.Vec3(gm.EmissiveFactor)
+
+	if gm.AlphaMode == "BLEND" {
+		m.Opacity = base.W()
+		m.State.Blend = true
+		m.State.DepthWrite = false
+	}
+	m.State.CullFace = !gm.DoubleSided
+
+	maps := []struct {
+		info *textureInfo
+		id   *int
+	}{
+		{pbr.BaseColorTexture, &m.DiffuseMap},
+		{gm.NormalTexture, &m.NormalMap},
+		{gm.EmissiveTexture, &m.EmissiveMap},
+	}
+	for _, tm := range maps {
+		if tm.info == nil {
+			continue
+		}
+		texID, err := imp.texture(tm.info.Index)
+		if err != nil {
+			m.Release(imp.r)
+			return nil, fmt.Errorf("material %d: %v", *idx, err)
+		}
+		*tm.id = texID
+	}
+
+	imp.materials[*idx] = m
+	return m, nil
+}
+
+// Loads the image behind a glTF texture
+// External images go through Renderer.LoadTexture, embedded ones are decoded here
+// The returned ID holds a reference for the caller
+func (imp *importer) texture(idx int) (int, error) {
+	// Every material holds its own reference, the importer keeps one until Load returns
+	if id, ok := imp.textures[idx]; ok {
+		return id, imp.r.AcquireTexture(id)
+	}
+	if idx < 0 || idx >= len(imp.doc.Textures) {
+		return 0, fmt.Errorf("texture %d out of range", idx)
+	}
+	src := imp.doc.Textures[idx].Source
+	if src == nil || *src < 0 || *src >= len(imp.doc.Images) {
+		return 0, fmt.Errorf("texture %d has no valid image", idx)
+	}
+	img := &imp.doc.Images[*src]
+	opts, err := imp.textureOptions(imp.doc.Textures[idx].Sampler)
+	if err != nil {
+		return 0, fmt.Errorf("texture %d: %v", idx, err)
+	}
+
+	var texID int
+	if img.BufferView == nil && !strings.HasPrefix(img.URI, "data:") {
+		path, err := imp.resolve(img.URI)
+		if err != nil {
+			return 0, err
+		}
+		if texID, err = imp.r.LoadTexture(path, imp.programID, opts); err != nil {
+			return 0, err
+		}
+	} else {
+		data, err := imp.imageData(img)
+		if err != nil {
+			return 0, fmt.Errorf("image %d: %v", *src, err)
+		}
+		decoded, _, err := image.Decode(data)
+		if err != nil {
+			return 0, fmt.Errorf("could not decode image %d: %v", *src, err)
+		}
+		if texID, err = imp.r.LoadTextureImage(decoded, imp.programID, opts); err != nil {
+			return 0, err
+		}
+	}
+	imp.textures[idx] = texID
+	return texID, imp.r.AcquireTexture(texID)
+}
+
+// Turns a glTF sampler into texture options
+// Anything the sampler leaves out keeps the renderer's default
+func (imp *importer) textureOptions(idx *int) (renderer.TextureOptions, error) {
+	opts := renderer.DefaultTextureOptions()
+	if idx == nil {
+		return opts, nil
+	}
+	if *idx < 0 || *idx >= len(imp.doc.Samplers) {
+		return opts, fmt.Errorf("sampler %d out of range", *idx)
+	}
+	smp := &imp.doc.Samplers[*idx]
+	if smp.MagFilter != nil {
+		opts.MagFilter = *smp.MagFilter
+	}
+	if smp.MinFilter != nil {
+		opts.MinFilter = *smp.MinFilter
+		// Only the mipmap filters need mipmaps
+		opts.Mipmaps = *smp.MinFilter != glNearest && *smp.MinFilter != glLinear
+	}
+	if smp.WrapS != nil {
+		opts.WrapS = *smp.WrapS
+	}
+	if smp.WrapT != nil {
+		opts.WrapT = *smp.WrapT
+	}
+	return opts, nil
+}
+
+// Places the scene camera where the first glTF camera is
+// glTF cameras look down their local -Z axis
+func (imp *importer) importCamera(idx int, n *scene.Node) {
+	if imp.cameraSet || idx < 0 || idx >= len(imp.doc.Cameras) {
+		return
+	}
+	c := &imp.doc.Cameras[idx]
+	if c.Type != "perspective" || c.Perspective == nil {
+		log.Printf("gltf: skipping %s camera %d, only perspective cameras are supported", c.Type, idx)
+		return
+	}
+	imp.cameraSet = true
+
+	world := n.ModelMatrix()
+	imp.sc.Cam.Position = world.Col(3).Vec3()
+	imp.sc.Cam.LookAlong(world.Col(2).Vec3().Mul(-1))
+
+	p := c.Perspective
+	ratio, far := imp.sc.AspectRatio, float32(scene.FAR)
+	if p.AspectRatio != nil {
+		ratio = *p.AspectRatio
+	}
+	if p.ZFar != nil {
+		far = *p.ZFar
+	}
+	imp.sc.SetPerspective(p.YFov, ratio, p.ZNear, far)
+}
+
+// Converts a KHR_lights_punctual light
+// Intensities are used as-is with inverse-square falloff, range is ignored
+func (imp *importer) importLight(idx int, n *scene.Node) error {
+	if imp.doc.Extensions.Lights == nil || idx < 0 || idx >= len(imp.doc.Extensions.Lights.Lights) {
+		return fmt.Errorf("light %d out of range", idx)
+	}
+	l := &imp.doc.Extensions.Lights.Lights[idx]
+	color, intensity := mgl32.Vec3{1, 1, 1}, float32(1)
+	if l.Color != nil {
+		color = mgl32.Vec3(*l.Color)
+	}
+	if l.Intensity != nil {
+		intensity = *l.Intensity
+	}
+	radiance := color.Mul(intensity)
+	world := n.ModelMatrix()
+
+	switch l.Type {
+	case "directional":
+		imp.sc.DirLight.Direction = world.Col(2).Vec3().Mul(-1)
+		imp.sc.DirLight.Diffuse = radiance
+		imp.sc.DirLight.Specular = radiance
+	case "spot", "point":
+		if l.Type == "spot" {
+			log.Printf("gltf: spot light %q is imported as a point light", l.Name)
+		}
+		pl := &scene.PointLight{
+			Position:  world.Col(3).Vec3(),
+			Diffuse:   radiance,
+			Specular:  radiance,
+			Constant:  1,
+			Quadratic: 1,
+		}
+		imp.sc.AddPointLight(pl)
+		imp.lights = append(imp.lights, pl)
+	default:
+		return fmt.Errorf("light %d: unknown type %q", idx, l.Type)
+	}
+	return nil
+}
@@ -0,0 +1,171 @@
+package gltf
+
+import (
+	"fmt"
+	"image"
+	"image/png"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/linosgian/goph3d/renderer"
+	"github.com/linosgian/goph3d/scene"
+)
+
+// Creates a renderer on a Recorder, restoring the GL backend when the test ends
+// Shaders are read relative to the repository root, the tests run in loader/gltf/
+func testRenderer(t *testing.T) (*renderer.Renderer, *renderer.Recorder) {
+	t.Helper()
+	if os.Getenv("PROJ_PATH") == "" {
+		wd, err := os.Getwd()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chdir(filepath.Join("..", "..")); err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { os.Chdir(wd) })
+	}
+	rec := renderer.NewRecorder()
+	prev := renderer.CurrentBackend()
+	renderer.SetBackend(rec)
+	t.Cleanup(func() { renderer.SetBackend(prev) })
+
+	r, err := renderer.NewRenderer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec.Reset()
+	return r, rec
+}
+
+// Counts the objects of a kind (e.g. "Textures") created and deleted so far
+func genDeleted(rec *renderer.Recorder, kind string) (gen, del int) {
+	for _, c := range rec.Calls("Gen" + kind) {
+		gen += int(c.Args[0].(int32))
+	}
+	for _, c := range rec.Calls("Delete" + kind) {
+		del += int(c.Args[0].(int32))
+	}
+	return gen, del
+}
+
+// Two textured triangles sharing a texture, a point and a directional light,
+// followed by the given scene nodes
+// Mesh 2 reads its positions from a VEC2 accessor, so importing it fails
+const importJSON = `{
+	"asset": {"version": "2.0"},
+	"scenes": [{"nodes": %s}],
+	"nodes": [
+		{"mesh": 0},
+		{"extensions": {"KHR_lights_punctual": {"light": 0}}},
+		{"extensions": {"KHR_lights_punctual": {"light": 1}}},
+		{"mesh": 1},
+		{"mesh": 2}
+	],
+	"meshes": [
+		{"primitives": [{"attributes": {"POSITION": 0}, "material": 0}]},
+		{"primitives": [{"attributes": {"POSITION": 0}, "material": 1}]},
+		{"primitives": [{"attributes": {"POSITION": 1}}]}
+	],
+	"materials": [
+		{"pbrMetallicRoughness": {"baseColorTexture": {"index": 0}}},
+		{"pbrMetallicRoughness": {"baseColorTexture": {"index": 0}}}
+	],
+	"textures": [{"source": 0}],
+	"images": [{"uri": "tex.png"}],
+	"buffers": [{"byteLength": 36}],
+	"bufferViews": [{"buffer": 0, "byteLength": 36}],
+	"accessors": [
+		{"bufferView": 0, "componentType": 5126, "count": 3, "type": "VEC3"},
+		{"bufferView": 0, "componentType": 5126, "count": 3, "type": "VEC2"}
+	],
+	"extensions": {"KHR_lights_punctual": {"lights": [
+		{"type": "point"},
+		{"type": "directional"}
+	]}}
+}`
+
+// Writes the importJSON file with the given root nodes and its texture
+func writeImportFile(t *testing.T, nodes string) string {
+	t.Helper()
+	dir := t.TempDir()
+	f, err := os.Create(filepath.Join(dir, "tex.png"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if err := png.Encode(f, image.NewNRGBA(image.Rect(0, 0, 2, 2))); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "scene.glb")
+	data := glb(fmt.Sprintf(importJSON, nodes), floatBytes(0, 0, 0, 1, 0, 0, 0, 1, 0))
+	if err := ioutil.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func testScene() *scene.Scene {
+	return scene.NewScene(1, scene.NewCamera(mgl32.Vec3{0, 0, 3}, 0, 0), []*scene.PointLight{{Constant: 1}})
+}
+
+func TestLoadReleasesOnError(t *testing.T) {
+	r, rec := testRenderer(t)
+	sc := testScene()
+	dirLight := sc.DirLight
+
+	if _, err := Load(writeImportFile(t, "[0, 1, 2, 3, 4]"), sc, r, "phong"); err == nil {
+		t.Fatal("a file with a broken mesh was imported")
+	}
+	if len(sc.Nodes) != 0 || len(sc.Root.Children) != 0 {
+		t.Errorf("the failed import left %d nodes in the scene", len(sc.Nodes))
+	}
+	if len(sc.PointLights) != 1 || sc.DirLight != dirLight {
+		t.Errorf("the failed import left %d point lights and directional light %v", len(sc.PointLights), sc.DirLight)
+	}
+	for _, kind := range []string{"Textures", "VertexArrays"} {
+		if gen, del := genDeleted(rec, kind); gen == 0 || gen != del {
+			t.Errorf("%d %s were created and %d deleted", gen, kind, del)
+		}
+	}
+}
+
+func TestLoadMaterialsOwnTextures(t *testing.T) {
+	r, rec := testRenderer(t)
+	sc := testScene()
+
+	root, err := Load(writeImportFile(t, "[0, 1, 2, 3]"), sc, r, "phong")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sc.PointLights) != 2 {
+		t.Errorf("got %d point lights, want 2", len(sc.PointLights))
+	}
+	if gen, del := genDeleted(rec, "Textures"); gen != 1 || del != 0 {
+		t.Fatalf("%d textures were created and %d deleted, want the shared one kept", gen, del)
+	}
+
+	// The texture goes away with the last material using it
+	mats := make(map[*scene.Material]bool)
+	root.Walk(func(n *scene.Node) {
+		if n.Material != nil {
+			mats[n.Material] = true
+		}
+	})
+	if len(mats) != 2 {
+		t.Fatalf("got %d materials, want 2", len(mats))
+	}
+	sc.RemoveNode(r, root)
+	for m := range mats {
+		if _, del := genDeleted(rec, "Textures"); del != 0 {
+			t.Fatal("the texture was deleted while a material still used it")
+		}
+		m.Release(r)
+	}
+	if _, del := genDeleted(rec, "Textures"); del != 1 {
+		t.Errorf("the texture was deleted %d times, want once", del)
+	}
+}
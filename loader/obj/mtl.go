@@ -0,0 +1,200 @@
+package obj
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/linosgian/goph3d/renderer"
+	"github.com/linosgian/goph3d/scene"
+)
+
+// Material as described by an MTL library
+// Texture maps are full paths, empty when the material has none
+type Material struct {
+	Name string
+
+	Ambient, Diffuse, Specular, Emissive mgl32.Vec3 // Ka, Kd, Ks, Ke
+	Shininess                            float32    // Ns
+	Opacity                              float32    // d, or 1 - Tr
+
+	DiffuseMap, SpecularMap, NormalMap, EmissiveMap string // map_Kd, map_Ks, bump/norm, map_Ke
+}
+
+// Reads an MTL library
+func LoadMTL(path string) (map[string]*Material, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not open mtl file: %v", err)
+	}
+	defer f.Close()
+	return ParseMTL(f, filepath.Base(path), filepath.Dir(path))
+}
+
+// Parses an MTL library
+// name is only used in errors, texture maps are resolved against dir
+func ParseMTL(r io.Reader, name, dir string) (map[string]*Material, error) {
+	mats := make(map[string]*Material)
+	var cur *Material
+
+	sc := bufio.NewScanner(r)
+	for line := 1; sc.Scan(); line++ {
+		text := sc.Text()
+		if i := strings.IndexByte(text, '#'); i >= 0 {
+			text = text[:i]
+		}
+		fields := strings.Fields(text)
+		if len(fields) == 0 {
+			continue
+		}
+		key, args := fields[0], fields[1:]
+
+		if key == "newmtl" {
+			cur = &Material{
+				Name:      strings.Join(args, " "),
+				Ambient:   mgl32.Vec3{1, 1, 1},
+				Diffuse:   mgl32.Vec3{1, 1, 1},
+				Shininess: 32,
+				Opacity:   1,
+			}
+			mats[cur.Name] = cur
+			continue
+		}
+		if cur == nil {
+			return nil, fmt.Errorf("%s:%d: %q before newmtl", name, line, key)
+		}
+
+		var err error
+		switch key {
+		case "Ka":
+			err = parseColor(args, &cur.Ambient)
+		case "Kd":
+			err = parseColor(args, &cur.Diffuse)
+		case "Ks":
+			err = parseColor(args, &cur.Specular)
+		case "Ke":
+			err = parseColor(args, &cur.Emissive)
+		case "Ns":
+			cur.Shininess, err = parseFloat(args)
+		case "d":
+			cur.Opacity, err = parseFloat(args)
+		case "Tr":
+			var tr float32
+			tr, err = parseFloat(args)
+			cur.Opacity = 1 - tr
+		case "map_Kd":
+			cur.DiffuseMap = mapPath(dir, args)
+		case "map_Ks":
+			cur.SpecularMap = mapPath(dir, args)
+		case "map_Bump", "map_bump", "bump", "norm":
+			cur.NormalMap = mapPath(dir, args)
+		case "map_Ke":
+			cur.EmissiveMap = mapPath(dir, args)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %v", name, line, err)
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %v", name, err)
+	}
+	return mats, nil
+}
+
+func parseFloat(args []string) (float32, error) {
+	if len(args) < 1 {
+		return 0, fmt.Errorf("missing value")
+	}
+	f, err := strconv.ParseFloat(args[0], 32)
+	if err != nil {
+		return 0, fmt.Errorf("invalid number %q", args[0])
+	}
+	return float32(f), nil
+}
+
+// Stores the color in c
+// Spectral ("Kd spectral file.rfl") and CIE XYZ ("Kd xyz x y z") colors are
+// not supported, they leave c unchanged
+func parseColor(args []string, c *mgl32.Vec3) error {
+	if len(args) < 1 {
+		return fmt.Errorf("missing color")
+	}
+	if args[0] == "spectral" || args[0] == "xyz" {
+		return nil
+	}
+	var rgb mgl32.Vec3
+	for i := range rgb {
+		// A single value is used for all three channels
+		a := args[0]
+		if i < len(args) {
+			a = args[i]
+		}
+		f, err := strconv.ParseFloat(a, 32)
+		if err != nil {
+			return fmt.Errorf("invalid color component %q", a)
+		}
+		rgb[i] = float32(f)
+	}
+	*c = rgb
+	return nil
+}
+
+// Texture maps may carry options (e.g. "-bm 1.0 normal.png"),
+// the file name always comes last
+func mapPath(dir string, args []string) string {
+	if len(args) == 0 {
+		return ""
+	}
+	p := filepath.FromSlash(args[len(args)-1])
+	if filepath.IsAbs(p) {
+		return p
+	}
+	return filepath.Join(dir, p)
+}
+
+// Creates a scene material drawn by a program (e.g. "phong")
+// and loads all of its texture maps
+// The caller owns the material, see scene.Material.Release
+func (m *Material) SceneMaterial(r *renderer.Renderer, programName string) (*scene.Material, error) {
+	sm, err := scene.NewMaterial(r, programName, m.DiffuseMap)
+	if err != nil {
+		return nil, err
+	}
+	sm.Name = m.Name
+	sm.Ambient = m.Ambient
+	sm.Diffuse = m.Diffuse
+	sm.Specular = m.Specular
+	sm.Emissive = m.Emissive
+	sm.Shininess = m.Shininess
+	sm.Opacity = m.Opacity
+	if m.Opacity < 1 {
+		sm.State.Blend = true
+		sm.State.DepthWrite = false
+	}
+
+	maps := []struct {
+		path string
+		id   *int
+	}{
+		{m.SpecularMap, &sm.SpecularMap},
+		{m.NormalMap, &sm.NormalMap},
+		{m.EmissiveMap, &sm.EmissiveMap},
+	}
+	for _, tm := range maps {
+		if tm.path == "" {
+			continue
+		}
+		texID, err := r.LoadTexture(tm.path, sm.Program, renderer.DefaultTextureOptions())
+		if err != nil {
+			sm.Release(r)
+			return nil, err
+		}
+		*tm.id = texID
+	}
+	return sm, nil
+}
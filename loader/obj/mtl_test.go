@@ -0,0 +1,90 @@
+package obj
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+func TestParseMTL(t *testing.T) {
+	src := `# Exported materials
+newmtl stone
+Ka 0.1 0.2 0.3
+Kd 0.5
+Ks 1 1 1 # trailing comment
+Ke 0 0 0.25
+Ns 64
+d 0.5
+map_Kd textures/stone.png
+map_Ks -clamp on spec.png
+bump -bm 1.0 normal.png
+map_Ke /abs/glow.png
+
+newmtl glass pane
+Tr 0.75
+Kd spectral glass.rfl
+Ka xyz 0.1 0.2 0.3
+`
+	mats, err := ParseMTL(strings.NewReader(src), "test.mtl", "models")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(mats) != 2 {
+		t.Fatalf("got %d materials, want 2", len(mats))
+	}
+
+	stone := mats["stone"]
+	want := Material{
+		Name:        "stone",
+		Ambient:     mgl32.Vec3{0.1, 0.2, 0.3},
+		Diffuse:     mgl32.Vec3{0.5, 0.5, 0.5},
+		Specular:    mgl32.Vec3{1, 1, 1},
+		Emissive:    mgl32.Vec3{0, 0, 0.25},
+		Shininess:   64,
+		Opacity:     0.5,
+		DiffuseMap:  filepath.Join("models", "textures", "stone.png"),
+		SpecularMap: filepath.Join("models", "spec.png"),
+		NormalMap:   filepath.Join("models", "normal.png"),
+		EmissiveMap: filepath.FromSlash("/abs/glow.png"),
+	}
+	if *stone != want {
+		t.Errorf("stone:\ngot  %+v\nwant %+v", *stone, want)
+	}
+
+	// Spectral and XYZ colors keep the defaults
+	glass := mats["glass pane"]
+	if glass == nil {
+		t.Fatal("material names may contain spaces")
+	}
+	if glass.Diffuse != (mgl32.Vec3{1, 1, 1}) || glass.Ambient != (mgl32.Vec3{1, 1, 1}) {
+		t.Errorf("unsupported colors changed the defaults: Kd %v, Ka %v", glass.Diffuse, glass.Ambient)
+	}
+	if glass.Opacity != 0.25 {
+		t.Errorf("Tr 0.75 gave opacity %v, want 0.25", glass.Opacity)
+	}
+	if glass.Shininess != 32 {
+		t.Errorf("default shininess is %v, want 32", glass.Shininess)
+	}
+}
+
+func TestParseMTLErrors(t *testing.T) {
+	tests := []struct {
+		name, src, err string
+	}{
+		{"before newmtl", "Kd 1 1 1\n", `test.mtl:1: "Kd" before newmtl`},
+		{"missing color", "newmtl a\nKd\n", "test.mtl:2: missing color"},
+		{"bad color", "newmtl a\nKa 1 x 1\n", `test.mtl:2: invalid color component "x"`},
+		{"missing value", "newmtl a\nNs\n", "test.mtl:2: missing value"},
+		{"bad number", "newmtl a\n\nd half\n", `test.mtl:3: invalid number "half"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseMTL(strings.NewReader(tt.src), "test.mtl", "")
+			if err == nil || err.Error() != tt.err {
+				t.Errorf("got error %v, want %q", err, tt.err)
+			}
+		})
+	}
+}
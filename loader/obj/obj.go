@@ -0,0 +1,348 @@
+// Package obj reads Wavefront OBJ files and their MTL material libraries
+// into indexed meshes that can be handed to scene.Scene.NewNode
+package obj
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// Number of floats per vertex: position, texture coordinates and normal,
+// i.e. the renderer's default layout
+const VertexSize = 8
+
+// Model is everything read from an OBJ file and its material libraries
+type Model struct {
+	Meshes    []*Mesh
+	Materials map[string]*Material
+}
+
+// Mesh is an indexed triangle mesh in the default position/texture/normal layout
+// Every object, group and material change in the file starts a new mesh
+type Mesh struct {
+	Name     string // Object or group name
+	Material string // Name given to usemtl, empty if none
+	Vertices []float32
+	Indices  []uint32
+}
+
+// A face corner, all indices are 0-based and -1 when missing
+type corner struct {
+	v, vt, vn int
+}
+
+type face struct {
+	corners   []corner
+	smoothing int // Smoothing group, 0 when smoothing is off
+}
+
+// Faces gathered for a mesh while parsing
+type meshBuilder struct {
+	name, material string
+	faces          []face
+}
+
+type parser struct {
+	name   string // File name, used in errors
+	dir    string // Directory material libraries are resolved against
+	line   int
+	pos    []mgl32.Vec3
+	uvs    []mgl32.Vec2
+	norms  []mgl32.Vec3
+	meshes []*meshBuilder
+
+	object, group, material string
+	smoothing               int
+	model                   *Model
+}
+
+// Reads an OBJ file along with the material libraries it references
+func Load(path string) (*Model, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not open obj file: %v", err)
+	}
+	defer f.Close()
+	return Parse(f, filepath.Base(path), filepath.Dir(path))
+}
+
+// Parses OBJ data
+// name is only used in errors, material libraries are looked up in dir
+func Parse(r io.Reader, name, dir string) (*Model, error) {
+	p := &parser{
+		name:  name,
+		dir:   dir,
+		model: &Model{Materials: make(map[string]*Material)},
+	}
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		p.line++
+		if err := p.parseLine(sc.Text()); err != nil {
+			return nil, err
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %v", name, err)
+	}
+
+	for _, mb := range p.meshes {
+		if len(mb.faces) == 0 {
+			continue
+		}
+		p.model.Meshes = append(p.model.Meshes, p.build(mb))
+	}
+	return p.model, nil
+}
+
+func (p *parser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("%s:%d: %s", p.name, p.line, fmt.Sprintf(format, args...))
+}
+
+func (p *parser) parseLine(line string) error {
+	if i := strings.IndexByte(line, '#'); i >= 0 {
+		line = line[:i]
+	}
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return nil
+	}
+	args := fields[1:]
+
+	switch fields[0] {
+	case "v":
+		v, err := p.floats(args, 3)
+		if err != nil {
+			return err
+		}
+		p.pos = append(p.pos, mgl32.Vec3{v[0], v[1], v[2]})
+	case "vt":
+		v, err := p.floats(args, 1)
+		if err != nil {
+			return err
+		}
+		uv := mgl32.Vec2{v[0], 0}
+		if len(v) > 1 {
+			uv[1] = v[1]
+		}
+		p.uvs = append(p.uvs, uv)
+	case "vn":
+		v, err := p.floats(args, 3)
+		if err != nil {
+			return err
+		}
+		p.norms = append(p.norms, mgl32.Vec3{v[0], v[1], v[2]})
+	case "f":
+		return p.parseFace(args)
+	case "o":
+		p.object = strings.Join(args, " ")
+		p.group = ""
+		p.startMesh()
+	case "g":
+		p.group = strings.Join(args, " ")
+		p.startMesh()
+	case "usemtl":
+		p.material = strings.Join(args, " ")
+		p.startMesh()
+	case "s":
+		if len(args) == 0 || args[0] == "off" {
+			p.smoothing = 0
+			return nil
+		}
+		s, err := strconv.Atoi(args[0])
+		if err != nil {
+			return p.errorf("invalid smoothing group %q", args[0])
+		}
+		p.smoothing = s
+	case "mtllib":
+		for _, lib := range args {
+			mats, err := LoadMTL(filepath.Join(p.dir, lib))
+			if err != nil {
+				return p.errorf("%v", err)
+			}
+			for name, m := range mats {
+				p.model.Materials[name] = m
+			}
+		}
+	}
+	// Anything else (e.g. lines, curves) is not supported and skipped
+	return nil
+}
+
+func (p *parser) floats(args []string, min int) ([]float32, error) {
+	if len(args) < min {
+		return nil, p.errorf("expected at least %d values, got %d", min, len(args))
+	}
+	v := make([]float32, len(args))
+	for i, a := range args {
+		f, err := strconv.ParseFloat(a, 32)
+		if err != nil {
+			return nil, p.errorf("invalid number %q", a)
+		}
+		v[i] = float32(f)
+	}
+	return v, nil
+}
+
+// Starts a new mesh for the current object, group and material
+func (p *parser) startMesh() {
+	name := p.object
+	if p.group != "" {
+		if name != "" {
+			name += "/"
+		}
+		name += p.group
+	}
+	// Reuse the current mesh if nothing was added to it yet
+	if n := len(p.meshes); n > 0 && len(p.meshes[n-1].faces) == 0 {
+		p.meshes[n-1].name = name
+		p.meshes[n-1].material = p.material
+		return
+	}
+	p.meshes = append(p.meshes, &meshBuilder{name: name, material: p.material})
+}
+
+func (p *parser) parseFace(args []string) error {
+	if len(args) < 3 {
+		return p.errorf("face with %d vertices", len(args))
+	}
+	f := face{smoothing: p.smoothing, corners: make([]corner, len(args))}
+	for i, a := range args {
+		parts := strings.Split(a, "/")
+		if len(parts) > 3 {
+			return p.errorf("invalid face vertex %q", a)
+		}
+		c := corner{v: -1, vt: -1, vn: -1}
+		var err error
+		if c.v, err = p.index(parts[0], len(p.pos)); err != nil {
+			return err
+		}
+		if len(parts) > 1 && parts[1] != "" {
+			if c.vt, err = p.index(parts[1], len(p.uvs)); err != nil {
+				return err
+			}
+		}
+		if len(parts) > 2 && parts[2] != "" {
+			if c.vn, err = p.index(parts[2], len(p.norms)); err != nil {
+				return err
+			}
+		}
+		f.corners[i] = c
+	}
+
+	if len(p.meshes) == 0 {
+		p.startMesh()
+	}
+	mb := p.meshes[len(p.meshes)-1]
+	mb.faces = append(mb.faces, f)
+	return nil
+}
+
+// Resolves a 1-based, possibly negative (relative) OBJ index
+func (p *parser) index(s string, count int) (int, error) {
+	i, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, p.errorf("invalid index %q", s)
+	}
+	if i < 0 {
+		i += count
+	} else {
+		i--
+	}
+	if i < 0 || i >= count {
+		return 0, p.errorf("index %s out of range", s)
+	}
+	return i, nil
+}
+
+// Identifies a unique output vertex
+// Corners without a normal get a generated one, shared per smoothing group,
+// or per face when smoothing is off
+type vertexKey struct {
+	v, vt, vn int
+	smoothing int
+	face      int
+}
+
+type smoothKey struct {
+	v, smoothing int
+}
+
+// Triangulates the faces of a mesh and builds its indexed vertex data
+func (p *parser) build(mb *meshBuilder) *Mesh {
+	m := &Mesh{Name: mb.name, Material: mb.material}
+
+	// n-gons are split into a triangle fan, which is correct for convex polygons
+	tris := make([][3]corner, 0, len(mb.faces))
+	smoothing := make([]int, 0, len(mb.faces))
+	for _, f := range mb.faces {
+		for i := 1; i+1 < len(f.corners); i++ {
+			tris = append(tris, [3]corner{f.corners[0], f.corners[i], f.corners[i+1]})
+			smoothing = append(smoothing, f.smoothing)
+		}
+	}
+
+	// Face normals for the corners that have no normal of their own
+	// The cross product is left unnormalized so larger faces weigh more
+	faceNormals := make([]mgl32.Vec3, len(tris))
+	smoothNormals := make(map[smoothKey]mgl32.Vec3)
+	for i, t := range tris {
+		a, b, c := p.pos[t[0].v], p.pos[t[1].v], p.pos[t[2].v]
+		n := b.Sub(a).Cross(c.Sub(a))
+		faceNormals[i] = n
+		if smoothing[i] == 0 {
+			continue
+		}
+		for _, c := range t {
+			if c.vn < 0 {
+				k := smoothKey{c.v, smoothing[i]}
+				smoothNormals[k] = smoothNormals[k].Add(n)
+			}
+		}
+	}
+
+	seen := make(map[vertexKey]uint32)
+	for i, t := range tris {
+		for _, c := range t {
+			k := vertexKey{v: c.v, vt: c.vt, vn: c.vn, face: -1}
+			var n mgl32.Vec3
+			switch {
+			case c.vn >= 0:
+				n = p.norms[c.vn]
+			case smoothing[i] != 0:
+				k.smoothing = smoothing[i]
+				n = smoothNormals[smoothKey{c.v, smoothing[i]}]
+			default:
+				k.face = i
+				n = faceNormals[i]
+			}
+
+			if idx, ok := seen[k]; ok {
+				m.Indices = append(m.Indices, idx)
+				continue
+			}
+			if c.vn < 0 && n.Len() > 0 {
+				n = n.Normalize()
+			}
+			var uv mgl32.Vec2
+			if c.vt >= 0 {
+				// Textures are uploaded rotated by 180 degrees (see renderer.ReadImageFile)
+				// while OBJ puts the UV origin at the bottom left, so only u is mirrored
+				uv = mgl32.Vec2{1 - p.uvs[c.vt][0], p.uvs[c.vt][1]}
+			}
+			pos := p.pos[c.v]
+
+			idx := uint32(len(m.Vertices) / VertexSize)
+			m.Vertices = append(m.Vertices, pos[0], pos[1], pos[2], uv[0], uv[1], n[0], n[1], n[2])
+			m.Indices = append(m.Indices, idx)
+			seen[k] = idx
+		}
+	}
+	return m
+}
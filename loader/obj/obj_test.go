@@ -0,0 +1,175 @@
+package obj
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+func parse(t *testing.T, src string) *Model {
+	t.Helper()
+	m, err := Parse(strings.NewReader(src), "test.obj", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+// Returns the normal of the vertex at index i
+func normalAt(m *Mesh, i uint32) mgl32.Vec3 {
+	v := m.Vertices[int(i)*VertexSize:]
+	return mgl32.Vec3{v[5], v[6], v[7]}
+}
+
+func TestParseFanTriangulation(t *testing.T) {
+	tests := []struct {
+		name, face string
+		indices    []uint32
+	}{
+		{"triangle", "f 1//1 2//1 3//1", []uint32{0, 1, 2}},
+		{"quad", "f 1//1 2//1 3//1 4//1", []uint32{0, 1, 2, 0, 2, 3}},
+		{"pentagon", "f 1//1 2//1 3//1 4//1 5//1", []uint32{0, 1, 2, 0, 2, 3, 0, 3, 4}},
+	}
+	verts := "v 0 0 0\nv 1 0 0\nv 1 1 0\nv 0.5 1.5 0\nv 0 1 0\nvn 0 0 1\n"
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := parse(t, verts+tt.face+"\n")
+			if len(m.Meshes) != 1 {
+				t.Fatalf("got %d meshes, want 1", len(m.Meshes))
+			}
+			mesh := m.Meshes[0]
+			if !reflect.DeepEqual(mesh.Indices, tt.indices) {
+				t.Errorf("got indices %v, want %v", mesh.Indices, tt.indices)
+			}
+			corners := len(strings.Fields(tt.face)) - 1
+			if got := len(mesh.Vertices) / VertexSize; got != corners {
+				t.Errorf("got %d vertices, want %d", got, corners)
+			}
+			for _, i := range mesh.Indices {
+				if n := normalAt(mesh, i); !n.ApproxEqual(mgl32.Vec3{0, 0, 1}) {
+					t.Errorf("vertex %d has normal %v", i, n)
+				}
+			}
+		})
+	}
+}
+
+func TestParseNegativeIndices(t *testing.T) {
+	head := "v 0 0 0\nv 1 0 0\nv 0 1 0\nvt 0 0\nvt 1 0\nvt 0 1\nvn 0 0 1\n"
+	abs := parse(t, head+"f 1/1/1 2/2/1 3/3/1\n").Meshes[0]
+	rel := parse(t, head+"f -3/-3/-1 -2/-2/-1 -1/-1/-1\n").Meshes[0]
+	if !reflect.DeepEqual(abs.Vertices, rel.Vertices) || !reflect.DeepEqual(abs.Indices, rel.Indices) {
+		t.Errorf("relative indices differ:\nabsolute %v %v\nrelative %v %v", abs.Vertices, abs.Indices, rel.Vertices, rel.Indices)
+	}
+
+	// Relative indices count from the last element read so far
+	m := parse(t, "v 0 0 0\nv 1 0 0\nv 0 1 0\nf -3 -2 -1\nv 5 5 5\nf 1 2 -1\n").Meshes[0]
+	i := int(m.Indices[5]) * VertexSize
+	if got := m.Vertices[i : i+3]; !reflect.DeepEqual(got, []float32{5, 5, 5}) {
+		t.Errorf("-1 after a fourth vertex resolved to %v", got)
+	}
+}
+
+func TestParseSmoothingGroups(t *testing.T) {
+	// Two triangles at a right angle sharing the edge 1-2
+	verts := "v 0 0 0\nv 1 0 0\nv 0 1 0\nv 0 0 1\n"
+	faces := func(s1, s2 string) string {
+		return verts + "s " + s1 + "\nf 1 2 3\ns " + s2 + "\nf 1 4 2\n"
+	}
+
+	t.Run("shared", func(t *testing.T) {
+		m := parse(t, faces("1", "1")).Meshes[0]
+		if got := len(m.Vertices) / VertexSize; got != 4 {
+			t.Fatalf("got %d vertices, want the shared edge stored once", got)
+		}
+		want := mgl32.Vec3{0, 1, 1}.Normalize()
+		for _, i := range []uint32{m.Indices[0], m.Indices[1]} {
+			if n := normalAt(m, i); !n.ApproxEqual(want) {
+				t.Errorf("shared vertex %d has normal %v, want %v", i, n, want)
+			}
+		}
+		if n := normalAt(m, m.Indices[2]); !n.ApproxEqual(mgl32.Vec3{0, 0, 1}) {
+			t.Errorf("unshared vertex has normal %v", n)
+		}
+	})
+
+	for _, groups := range [][2]string{{"off", "off"}, {"0", "0"}, {"1", "2"}} {
+		t.Run("s "+groups[0]+"/"+groups[1], func(t *testing.T) {
+			m := parse(t, faces(groups[0], groups[1])).Meshes[0]
+			if got := len(m.Vertices) / VertexSize; got != 6 {
+				t.Fatalf("got %d vertices, want every face to have its own", got)
+			}
+			for k, want := range []mgl32.Vec3{{0, 0, 1}, {0, 1, 0}} {
+				for _, i := range m.Indices[k*3 : k*3+3] {
+					if n := normalAt(m, i); !n.ApproxEqual(want) {
+						t.Errorf("face %d vertex %d has normal %v, want %v", k, i, n, want)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestParseMeshes(t *testing.T) {
+	m := parse(t, `v 0 0 0
+v 1 0 0
+v 0 1 0
+o box
+f 1 2 3
+g lid
+usemtl wood
+f 1 2 3
+usemtl metal
+g empty
+o handle
+f 1 2 3
+`)
+	want := [][2]string{{"box", ""}, {"box/lid", "wood"}, {"handle", "metal"}}
+	if len(m.Meshes) != len(want) {
+		t.Fatalf("got %d meshes, want %d", len(m.Meshes), len(want))
+	}
+	for i, w := range want {
+		if got := [2]string{m.Meshes[i].Name, m.Meshes[i].Material}; got != w {
+			t.Errorf("mesh %d is %q, want %q", i, got, w)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	verts := "v 0 0 0\nv 1 0 0\nv 0 1 0\n"
+	tests := []struct {
+		name, src, err string
+	}{
+		{"short face", verts + "f 1 2\n", "test.obj:4: face with 2 vertices"},
+		{"zero index", verts + "f 0 1 2\n", "test.obj:4: index 0 out of range"},
+		{"past the end", verts + "f 1 2 4\n", "test.obj:4: index 4 out of range"},
+		{"relative past the start", verts + "f -1 -2 -4\n", "test.obj:4: index -4 out of range"},
+		{"missing normal", verts + "f 1//1 2//1 3//1\n", "test.obj:4: index 1 out of range"},
+		{"bad index", verts + "f 1 2 x\n", `test.obj:4: invalid index "x"`},
+		{"bad vertex", "v 0 zero 0\n", `test.obj:1: invalid number "zero"`},
+		{"short vertex", "v 0 0\n", "test.obj:1: expected at least 3 values, got 2"},
+		{"bad smoothing group", "s on\n", `test.obj:1: invalid smoothing group "on"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(strings.NewReader(tt.src), "test.obj", "")
+			if err == nil || err.Error() != tt.err {
+				t.Errorf("got error %v, want %q", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestParseTexCoords(t *testing.T) {
+	m := parse(t, "v 0 0 0\nv 1 0 0\nv 0 1 0\nvt 0 0\nvt 0.25 0.5\nvt 1 1\nf 1/1 2/2 3/3\nf 1 2 3\n").Meshes[0]
+	// Textures are uploaded rotated by 180 degrees, so u is mirrored while v stays
+	want := [][2]float32{{1, 0}, {0.75, 0.5}, {0, 1}, {0, 0}, {0, 0}, {0, 0}}
+	for k, i := range m.Indices {
+		v := m.Vertices[int(i)*VertexSize:]
+		if got := [2]float32{v[3], v[4]}; got != want[k] {
+			t.Errorf("corner %d has uv %v, want %v", k, got, want[k])
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package obj
+
+import (
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/linosgian/goph3d/renderer"
+	"github.com/linosgian/goph3d/scene"
+)
+
+// Returns the mesh as geometry for scene.Scene.NewNode
+// The uploaded mesh is anonymous, use Renderer.LoadMesh to share it by name
+func (m *Mesh) Geometry() scene.RawGeometry {
+	return scene.RawGeometry{Vertices: m.Vertices, Indices: m.Indices}
+}
+
+// Adds every mesh of the model to the scene, as children of a group node at pos
+// Meshes are drawn by programName with their MTL material,
+// or with a plain white material when they have none
+// The materials belong to the caller, who releases them once the group is removed
+// Returns the group node
+func (m *Model) AddToScene(sc *scene.Scene, r *renderer.Renderer, name, programName string, pos mgl32.Vec3) (*scene.Node, error) {
+	group := sc.NewGroup(name, pos)
+
+	// Meshes using the same material share it
+	mats := make(map[string]*scene.Material)
+	// Nothing of a model that failed halfway stays in the scene
+	fail := func(err error) (*scene.Node, error) {
+		sc.RemoveNode(r, group)
+		for _, mat := range mats {
+			mat.Release(r)
+		}
+		return nil, err
+	}
+	for _, mesh := range m.Meshes {
+		mat, ok := mats[mesh.Material]
+		if !ok {
+			var err error
+			if objMat, found := m.Materials[mesh.Material]; found {
+				mat, err = objMat.SceneMaterial(r, programName)
+			} else {
+				mat, err = scene.NewMaterial(r, programName, "")
+			}
+			if err != nil {
+				return fail(err)
+			}
+			mats[mesh.Material] = mat
+		}
+
+		n, err := sc.NewNode(r, mesh.Name, true, mesh.Geometry(), mat, mgl32.Vec3{})
+		if err != nil {
+			return fail(err)
+		}
+		group.AddChild(n)
+	}
+	return group, nil
+}
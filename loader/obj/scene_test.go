@@ -0,0 +1,121 @@
+package obj
+
+import (
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/linosgian/goph3d/renderer"
+	"github.com/linosgian/goph3d/scene"
+)
+
+// Creates a renderer on a Recorder, restoring the GL backend when the test ends
+// Shaders are read relative to the repository root, the tests run in loader/obj/
+func testRenderer(t *testing.T) (*renderer.Renderer, *renderer.Recorder) {
+	t.Helper()
+	if os.Getenv("PROJ_PATH") == "" {
+		wd, err := os.Getwd()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chdir(filepath.Join("..", "..")); err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { os.Chdir(wd) })
+	}
+	rec := renderer.NewRecorder()
+	prev := renderer.CurrentBackend()
+	renderer.SetBackend(rec)
+	t.Cleanup(func() { renderer.SetBackend(prev) })
+
+	r, err := renderer.NewRenderer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec.Reset()
+	return r, rec
+}
+
+// Writes a tiny PNG and returns its path
+func writePNG(t *testing.T, dir, name string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if err := png.Encode(f, image.NewNRGBA(image.Rect(0, 0, 2, 2))); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// Fails the test unless every object of the kinds (e.g. "Textures") created so far was deleted again
+func checkDeleted(t *testing.T, rec *renderer.Recorder, kinds ...string) {
+	t.Helper()
+	for _, kind := range kinds {
+		gen, del := 0, 0
+		for _, c := range rec.Calls("Gen" + kind) {
+			gen += int(c.Args[0].(int32))
+		}
+		for _, c := range rec.Calls("Delete" + kind) {
+			del += int(c.Args[0].(int32))
+		}
+		if gen == 0 || gen != del {
+			t.Errorf("%d %s were created and %d deleted", gen, kind, del)
+		}
+	}
+}
+
+func TestSceneMaterialReleasesOnError(t *testing.T) {
+	r, rec := testRenderer(t)
+	dir := t.TempDir()
+	m := &Material{
+		Name:        "broken",
+		Opacity:     1,
+		DiffuseMap:  writePNG(t, dir, "diffuse.png"),
+		SpecularMap: writePNG(t, dir, "specular.png"),
+		NormalMap:   filepath.Join(dir, "missing.png"),
+	}
+	if _, err := m.SceneMaterial(r, "phong"); err == nil {
+		t.Fatal("a material with a missing map was created")
+	}
+	if n := len(rec.Calls("GenTextures")); n != 2 {
+		t.Fatalf("loaded %d textures, want 2", n)
+	}
+	checkDeleted(t, rec, "Textures")
+}
+
+func TestAddToSceneCleansUp(t *testing.T) {
+	r, rec := testRenderer(t)
+	dir := t.TempDir()
+	diffuse := writePNG(t, dir, "diffuse.png")
+	tri := []float32{
+		0, 0, 0, 0, 0, 0, 0, 1,
+		1, 0, 0, 1, 0, 0, 0, 1,
+		0, 1, 0, 0, 1, 0, 0, 1,
+	}
+	model := &Model{
+		Meshes: []*Mesh{
+			{Name: "good", Material: "good", Vertices: tri, Indices: []uint32{0, 1, 2}},
+			{Name: "plain", Vertices: tri, Indices: []uint32{0, 1, 2}},
+			{Name: "bad", Material: "bad", Vertices: tri, Indices: []uint32{0, 1, 2}},
+		},
+		Materials: map[string]*Material{
+			"good": {Name: "good", Opacity: 1, DiffuseMap: diffuse},
+			"bad":  {Name: "bad", Opacity: 1, DiffuseMap: diffuse, EmissiveMap: filepath.Join(dir, "missing.png")},
+		},
+	}
+	sc := scene.NewScene(1, scene.NewCamera(mgl32.Vec3{}, 0, 0), nil)
+	if _, err := model.AddToScene(sc, r, "model", "phong", mgl32.Vec3{}); err == nil {
+		t.Fatal("a model with a missing map was added")
+	}
+	if len(sc.Nodes) != 0 || len(sc.Root.Children) != 0 {
+		t.Errorf("the failed model left %d nodes in the scene", len(sc.Nodes))
+	}
+	checkDeleted(t, rec, "Textures", "VertexArrays")
+}
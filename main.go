@@ -1,15 +1,17 @@
 package main
 
 import (
+	"flag"
 	"log"
 	"os"
 	"path"
 	"runtime"
+	"time"
 
 	"github.com/go-gl/gl/v4.3-core/gl"
 	"github.com/go-gl/glfw/v3.2/glfw"
 	"github.com/go-gl/mathgl/mgl32"
-	"github.com/kpelelis/go-engine/objloader"
+	"github.com/linosgian/goph3d/loader/obj"
 	"github.com/linosgian/goph3d/renderer"
 	"github.com/linosgian/goph3d/scene"
 	"github.com/linosgian/goph3d/window"
@@ -18,8 +20,12 @@ import (
 const (
 	metalPath  = "res/textures/wood.png"
 	marblePath = "res/textures/marble.jpg"
+	cubePath   = "res/models/cube.obj"
 
 	FOV = 55.0
+
+	// Frame rate of Y4M captures, headless frames are 1/60s apart too
+	captureFPS = 60
 )
 
 // This should be given temporarily because of vim-go
@@ -30,12 +36,24 @@ func init() {
 }
 
 func main() {
+	headless := flag.Bool("headless", false, "render offscreen without a window, needs a build with -tags egl")
+	frames := flag.Int("frames", 1, "number of frames to render in headless mode, 0 for no limit")
+	capturePath := flag.String("capture", "", "where F11 (or headless mode, from the first frame) captures frames to: a .y4m file, or PNG files like frames/%04d.png")
+	captureFrames := flag.Int("capture-frames", 60, "number of frames to capture")
+	flag.Parse()
+
 	//Initialize camera object at a certain position
 	cam := scene.NewCamera(mgl32.Vec3{0, 2, 17}, window.WIDTH/2.0, window.HEIGHT/2.0)
 
-	// Initializes Graphics API and GLFW
+	// Initializes Graphics API and GLFW, or an offscreen context
 	// NOTE: A pointer to a camera is needed in order for the mouse callback to control it
-	w, err := window.Init(cam, MouseCallback)
+	var w window.Context
+	var err error
+	if *headless {
+		w, err = window.InitHeadless(window.WIDTH, window.HEIGHT, *frames)
+	} else {
+		w, err = window.Init(cam, MouseCallback)
+	}
 	if err != nil {
 		log.Fatalf("Window initialization failed: %q\n", err)
 	}
@@ -43,7 +61,8 @@ func main() {
 	// Temp: Light position
 	lightPos := mgl32.Vec3{1.2, 2.3, 2}
 
-	aspectRatio := float32(window.WIDTH) / window.HEIGHT
+	width, height := w.Size()
+	aspectRatio := float32(width) / float32(height)
 
 	// Create all Point lights
 	lights := make([]*scene.PointLight, 0)
@@ -68,54 +87,137 @@ func main() {
 
 	// Instantiate all scene nodes and set their model matrices
 	// -----------------------------
-	reader, err := objloader.New("/home/lgian/Desktop/obj.obj")
+	model, err := obj.Load(path.Join(rootPath, cubePath))
 	if err != nil {
 		log.Fatalf("Could not read file: %q\n", err)
 	}
-	reader.Read()
-	reader.Close()
-	cube := reader.ExportToFloat32Array()[0]
-	if err := sc.NewNodes(r, "crate", true, cube, path.Join(rootPath, marblePath), "phong", cubePositions); err != nil {
+	cube := model.Meshes[0]
+
+	// Every kind of object gets its own material
+	crateMat, err := scene.NewMaterial(r, "phong", path.Join(rootPath, marblePath))
+	if err != nil {
+		log.Fatalf("Could not create material: %q\n", err)
+	}
+	crateMat.Ambient = mgl32.Vec3{1.0, 0.5, 0.31}
+	crateMat.Diffuse = mgl32.Vec3{1.0, 0.5, 0.31}
+
+	floorMat, err := scene.NewMaterial(r, "phong", path.Join(rootPath, metalPath))
+	if err != nil {
+		log.Fatalf("Could not create material: %q\n", err)
+	}
+	floorMat.Specular = mgl32.Vec3{0.2, 0.2, 0.2}
+	floorMat.Shininess = 8
+
+	lampMat, err := scene.NewMaterial(r, "lamp", "")
+	if err != nil {
+		log.Fatalf("Could not create material: %q\n", err)
+	}
+
+	// The crates and the lamp share the same cube on the GPU
+	cubeMesh, err := r.LoadMesh("cube", cube.Vertices, cube.Indices, nil)
+	if err != nil {
+		log.Fatalf("Could not load mesh: %q\n", err)
+	}
+
+	crates, err := sc.NewNodes(r, "crate", true, cubeMesh, crateMat, cubePositions)
+	if err != nil {
 		log.Fatalf("Could not create node: %q\n", err)
 	}
 
-	if err := sc.NewNode(r, "lamp", true, cube, path.Join(rootPath, marblePath), "lamp", lightPos); err != nil {
+	if _, err := sc.NewNode(r, "lamp", true, cubeMesh, lampMat, lightPos); err != nil {
 		log.Fatalf("Could not create node: %q\n", err)
 	}
 
-	if err := sc.NewNode(r, "plane", true, planeVertices, path.Join(rootPath, metalPath), "phong", mgl32.Vec3{0, 0, 0}); err != nil {
+	plane := scene.RawGeometry{Name: "plane", Vertices: planeVertices}
+	if _, err := sc.NewNode(r, "plane", true, plane, floorMat, mgl32.Vec3{0, 0, 0}); err != nil {
 		log.Fatalf("Could not create node: %q\n", err)
 	}
 	// ----------------------------
 
-	gl.Enable(gl.DEPTH_TEST)
+	renderer.CurrentBackend().Enable(gl.DEPTH_TEST)
+
+	// F12 saves a screenshot, F11 starts capturing frames
+	var screenshot bool
+	var capture *renderer.FrameCapture
+	startCapture := func() {
+		if *capturePath == "" {
+			log.Println("no capture path given, see -capture")
+			return
+		}
+		if capture != nil && !capture.Done() {
+			return
+		}
+		if capture, err = renderer.NewFrameCapture(*capturePath, *captureFrames, captureFPS); err != nil {
+			log.Println(err)
+		}
+	}
+	if gw, ok := w.(*window.GlWindow); ok {
+		gw.SetKeyCallback(func(_ *glfw.Window, key glfw.Key, _ int, action glfw.Action, _ glfw.ModifierKey) {
+			if action != glfw.Press {
+				return
+			}
+			switch key {
+			case glfw.KeyF12:
+				screenshot = true
+			case glfw.KeyF11:
+				startCapture()
+			}
+		})
+	} else if *capturePath != "" {
+		startCapture()
+	}
 
 	sc.InitLights(r)
 	for !w.ShouldClose() {
 		// Per-frame time. Used for speed normalization
-		currentFrame := glfw.GetTime()
+		currentFrame := w.Time()
 		sc.DeltaTime = currentFrame - sc.LastFrame
 		sc.LastFrame = currentFrame
 
+		// Pick up any edited shaders, broken ones keep their last good version
+		if err := r.ReloadPrograms(); err != nil {
+			log.Println(err)
+		}
+
 		w.Clear()
-		processInput(w, sc)
+		if gw, ok := w.(*window.GlWindow); ok {
+			processInput(gw, sc)
+		}
 
 		// Update everything per-frame
 		sc.Update(r)
 
 		// Rotate all crates according to current time
-		view := sc.Cam.GetViewMatrix()
-		rot := mgl32.HomogRotate3D(float32(glfw.GetTime()), mgl32.Vec3{0, 1, 0})
-		for _, n := range sc.Nodes {
-			if n.Name == "crate" {
-				translate := mgl32.Translate3D(n.Position.X(), n.Position.Y(), n.Position.Z())
-				n.SetModelMatrix(translate.Mul4(rot))
+		rot := mgl32.QuatRotate(float32(currentFrame), mgl32.Vec3{0, 1, 0})
+		for _, n := range crates {
+			n.SetRotation(rot)
+		}
+		// The crates are a single batch, drawn in one instanced draw call
+		sc.Draw(r)
+
+		// Read back before the frame is presented
+		if screenshot {
+			screenshot = false
+			name := time.Now().Format("screenshot-20060102-150405.png")
+			if err := renderer.SavePNG(name, r.Screenshot()); err != nil {
+				log.Println(err)
+			} else {
+				log.Println("saved", name)
+			}
+		}
+		if capture != nil && !capture.Done() {
+			if err := capture.Capture(r); err != nil {
+				log.Println(err)
+				capture.Close()
 			}
-			r.DrawRaw(n.VaoID, n.ProgramID, n.TexID, view, sc.Perspective, n.ModelMatrix)
 		}
 
-		w.SwapBuffers()
-		glfw.PollEvents()
+		w.EndFrame()
+	}
+	if capture != nil {
+		if err := capture.Close(); err != nil {
+			log.Println(err)
+		}
 	}
 	w.Destroy()
 }
@@ -0,0 +1,147 @@
+package renderer
+
+import (
+	"unsafe"
+
+	"github.com/go-gl/gl/v4.3-core/gl"
+)
+
+// The OpenGL functions the renderer calls, with the signatures of go-gl
+// All GL calls of the package go through the current backend, so it can be swapped
+// for a Recorder and the command stream checked without a GPU
+type Backend interface {
+	// State
+	Enable(cap uint32)
+	Disable(cap uint32)
+	DepthFunc(xfunc uint32)
+	DepthMask(flag bool)
+	BlendFunc(sfactor uint32, dfactor uint32)
+	CullFace(mode uint32)
+	FrontFace(mode uint32)
+	Viewport(x int32, y int32, width int32, height int32)
+	ClearColor(r float32, g float32, b float32, a float32)
+	Clear(mask uint32)
+	Finish()
+	PixelStorei(pname uint32, param int32)
+	GetIntegerv(pname uint32, data *int32)
+	GetFloatv(pname uint32, data *float32)
+	DebugMessageCallback(callback gl.DebugProc, userParam unsafe.Pointer)
+
+	// Buffers
+	GenBuffers(n int32, buffers *uint32)
+	DeleteBuffers(n int32, buffers *uint32)
+	BindBuffer(target uint32, buffer uint32)
+	BindBufferBase(target uint32, index uint32, buffer uint32)
+	BufferData(target uint32, size int, data unsafe.Pointer, usage uint32)
+	BufferSubData(target uint32, offset int, size int, data unsafe.Pointer)
+
+	// Vertex arrays and draw calls
+	GenVertexArrays(n int32, arrays *uint32)
+	DeleteVertexArrays(n int32, arrays *uint32)
+	BindVertexArray(array uint32)
+	EnableVertexAttribArray(index uint32)
+	VertexAttribPointer(index uint32, size int32, xtype uint32, normalized bool, stride int32, pointer unsafe.Pointer)
+	VertexAttribDivisor(index uint32, divisor uint32)
+	DrawArrays(mode uint32, first int32, count int32)
+	DrawArraysInstanced(mode uint32, first int32, count int32, instancecount int32)
+	DrawElements(mode uint32, count int32, xtype uint32, indices unsafe.Pointer)
+	DrawElementsInstanced(mode uint32, count int32, xtype uint32, indices unsafe.Pointer, instancecount int32)
+
+	// Textures
+	GenTextures(n int32, textures *uint32)
+	DeleteTextures(n int32, textures *uint32)
+	ActiveTexture(texture uint32)
+	BindTexture(target uint32, texture uint32)
+	TexImage2D(target uint32, level int32, internalformat int32, width int32, height int32, border int32, format uint32, xtype uint32, pixels unsafe.Pointer)
+	TexImage3D(target uint32, level int32, internalformat int32, width int32, height int32, depth int32, border int32, format uint32, xtype uint32, pixels unsafe.Pointer)
+	TexSubImage3D(target uint32, level int32, xoffset int32, yoffset int32, zoffset int32, width int32, height int32, depth int32, format uint32, xtype uint32, pixels unsafe.Pointer)
+	TexParameteri(target uint32, pname uint32, param int32)
+	TexParameterf(target uint32, pname uint32, param float32)
+	TexParameterfv(target uint32, pname uint32, params *float32)
+	GenerateMipmap(target uint32)
+
+	// Framebuffers
+	GenFramebuffers(n int32, framebuffers *uint32)
+	DeleteFramebuffers(n int32, framebuffers *uint32)
+	BindFramebuffer(target uint32, framebuffer uint32)
+	FramebufferTexture2D(target uint32, attachment uint32, textarget uint32, texture uint32, level int32)
+	GenRenderbuffers(n int32, renderbuffers *uint32)
+	DeleteRenderbuffers(n int32, renderbuffers *uint32)
+	BindRenderbuffer(target uint32, renderbuffer uint32)
+	RenderbufferStorage(target uint32, internalformat uint32, width int32, height int32)
+	FramebufferRenderbuffer(target uint32, attachment uint32, renderbuffertarget uint32, renderbuffer uint32)
+	CheckFramebufferStatus(target uint32) uint32
+	DrawBuffers(n int32, bufs *uint32)
+	DrawBuffer(buf uint32)
+	ReadBuffer(src uint32)
+	ReadPixels(x int32, y int32, width int32, height int32, format uint32, xtype uint32, pixels unsafe.Pointer)
+
+	// Shaders and programs
+	CreateShader(xtype uint32) uint32
+	ShaderSource(shader uint32, count int32, xstring **uint8, length *int32)
+	CompileShader(shader uint32)
+	GetShaderiv(shader uint32, pname uint32, params *int32)
+	GetShaderInfoLog(shader uint32, bufSize int32, length *int32, infoLog *uint8)
+	DeleteShader(shader uint32)
+	CreateProgram() uint32
+	AttachShader(program uint32, shader uint32)
+	LinkProgram(program uint32)
+	GetProgramiv(program uint32, pname uint32, params *int32)
+	GetProgramInfoLog(program uint32, bufSize int32, length *int32, infoLog *uint8)
+	UseProgram(program uint32)
+	DeleteProgram(program uint32)
+	GetActiveUniform(program uint32, index uint32, bufSize int32, length *int32, size *int32, xtype *uint32, name *uint8)
+	GetActiveAttrib(program uint32, index uint32, bufSize int32, length *int32, size *int32, xtype *uint32, name *uint8)
+	GetAttribLocation(program uint32, name *uint8) int32
+	GetUniformLocation(program uint32, name *uint8) int32
+	GetUniformBlockIndex(program uint32, uniformBlockName *uint8) uint32
+	GetActiveUniformBlockName(program uint32, uniformBlockIndex uint32, bufSize int32, length *int32, uniformBlockName *uint8)
+	GetActiveUniformBlockiv(program uint32, uniformBlockIndex uint32, pname uint32, params *int32)
+	UniformBlockBinding(program uint32, uniformBlockIndex uint32, uniformBlockBinding uint32)
+
+	// Uniforms
+	Uniform1f(location int32, v0 float32)
+	Uniform2f(location int32, v0 float32, v1 float32)
+	Uniform3f(location int32, v0 float32, v1 float32, v2 float32)
+	Uniform4f(location int32, v0 float32, v1 float32, v2 float32, v3 float32)
+	Uniform1i(location int32, v0 int32)
+	Uniform2i(location int32, v0 int32, v1 int32)
+	Uniform3i(location int32, v0 int32, v1 int32, v2 int32)
+	Uniform4i(location int32, v0 int32, v1 int32, v2 int32, v3 int32)
+	Uniform1ui(location int32, v0 uint32)
+	Uniform2ui(location int32, v0 uint32, v1 uint32)
+	Uniform3ui(location int32, v0 uint32, v1 uint32, v2 uint32)
+	Uniform4ui(location int32, v0 uint32, v1 uint32, v2 uint32, v3 uint32)
+	Uniform1fv(location int32, count int32, value *float32)
+	Uniform2fv(location int32, count int32, value *float32)
+	Uniform3fv(location int32, count int32, value *float32)
+	Uniform4fv(location int32, count int32, value *float32)
+	Uniform1iv(location int32, count int32, value *int32)
+	Uniform2iv(location int32, count int32, value *int32)
+	Uniform3iv(location int32, count int32, value *int32)
+	Uniform4iv(location int32, count int32, value *int32)
+	Uniform1uiv(location int32, count int32, value *uint32)
+	Uniform2uiv(location int32, count int32, value *uint32)
+	Uniform3uiv(location int32, count int32, value *uint32)
+	Uniform4uiv(location int32, count int32, value *uint32)
+	UniformMatrix2fv(location int32, count int32, transpose bool, value *float32)
+	UniformMatrix3fv(location int32, count int32, transpose bool, value *float32)
+	UniformMatrix4fv(location int32, count int32, transpose bool, value *float32)
+}
+
+// Backend every GL call of the package goes through, see SetBackend
+var backend Backend = glBackend{}
+
+// Replaces the backend, nil goes back to calling OpenGL
+// Only swap it before creating a Renderer, objects made through one backend mean nothing to another
+func SetBackend(b Backend) {
+	if b == nil {
+		b = glBackend{}
+	}
+	backend = b
+}
+
+// Returns the backend in use, for code outside the package that sets GL state (e.g. clearing)
+func CurrentBackend() Backend {
+	return backend
+}
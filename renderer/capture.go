@@ -0,0 +1,139 @@
+package renderer
+
+import (
+	"fmt"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/disintegration/imaging"
+	"github.com/go-gl/gl/v4.3-core/gl"
+)
+
+// Reads back a rectangle of the framebuffer being drawn to, e.g. a Framebuffer while it is bound
+// x and y are the bottom left corner, as GL counts them
+// The image has its origin at the top left, like any image file
+func (r *Renderer) ReadPixels(x, y, width, height int) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	backend.PixelStorei(gl.PACK_ALIGNMENT, 1)
+	backend.ReadPixels(int32(x), int32(y), int32(width), int32(height), gl.RGBA, gl.UNSIGNED_BYTE, gl.Ptr(img.Pix))
+
+	// Rows come bottom up, the opposite of what ReadImageFile does when loading.
+	// Only a vertical flip is needed, nothing was mirrored on the way out
+	img = imaging.FlipV(img)
+
+	// Blending leaves arbitrary values in the alpha channel,
+	// which would make the image translucent outside of GL
+	for i := 3; i < len(img.Pix); i += 4 {
+		img.Pix[i] = 0xff
+	}
+	return img
+}
+
+// Reads back everything in the current viewport, see ReadPixels
+func (r *Renderer) Screenshot() *image.NRGBA {
+	var vp [4]int32
+	backend.GetIntegerv(gl.VIEWPORT, &vp[0])
+	return r.ReadPixels(int(vp[0]), int(vp[1]), int(vp[2]), int(vp[3]))
+}
+
+// Writes an image as a PNG file
+func SavePNG(path string, img image.Image) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("could not create image file: %v", err)
+	}
+	if err := png.Encode(f, img); err != nil {
+		f.Close()
+		return fmt.Errorf("could not encode %s: %v", path, err)
+	}
+	return f.Close()
+}
+
+// Records a number of consecutive frames, either as numbered PNG files or as a Y4M stream
+type FrameCapture struct {
+	pattern   string // fmt pattern of the PNG files, e.g. "frames/%04d.png"
+	y4m       *y4mWriter
+	frames    int // Frames captured so far
+	remaining int
+}
+
+// Captures frames to numbered PNG files, e.g. "frames/%04d.png"
+// A pattern without a verb gets the frame number before its extension
+func NewPNGSequence(pattern string, frames int) (*FrameCapture, error) {
+	if frames <= 0 {
+		return nil, fmt.Errorf("invalid number of frames: %d", frames)
+	}
+	if !strings.Contains(pattern, "%") {
+		ext := filepath.Ext(pattern)
+		pattern = strings.TrimSuffix(pattern, ext) + "%04d" + ext
+	}
+	return &FrameCapture{pattern: pattern, remaining: frames}, nil
+}
+
+// Captures frames to a Y4M (YUV4MPEG2) stream, which ffmpeg and most players read as is
+// All frames must have the same size
+func NewY4MCapture(path string, frames, fps int) (*FrameCapture, error) {
+	if frames <= 0 {
+		return nil, fmt.Errorf("invalid number of frames: %d", frames)
+	}
+	w, err := newY4MWriter(path, fps)
+	if err != nil {
+		return nil, err
+	}
+	return &FrameCapture{y4m: w, remaining: frames}, nil
+}
+
+// Captures to a Y4M stream if the path ends in .y4m, to PNG files otherwise
+func NewFrameCapture(path string, frames, fps int) (*FrameCapture, error) {
+	if strings.EqualFold(filepath.Ext(path), ".y4m") {
+		return NewY4MCapture(path, frames, fps)
+	}
+	return NewPNGSequence(path, frames)
+}
+
+// Adds the current viewport as the next frame, see Screenshot
+// The capture closes itself after its last frame
+func (c *FrameCapture) Capture(r *Renderer) error {
+	return c.Add(r.Screenshot())
+}
+
+// Adds an image as the next frame
+func (c *FrameCapture) Add(img *image.NRGBA) error {
+	if c.Done() {
+		return fmt.Errorf("frame capture is done")
+	}
+	var err error
+	if c.y4m != nil {
+		err = c.y4m.writeFrame(img)
+	} else {
+		err = SavePNG(fmt.Sprintf(c.pattern, c.frames), img)
+	}
+	if err != nil {
+		return err
+	}
+	c.frames++
+	c.remaining--
+	if c.remaining == 0 {
+		return c.Close()
+	}
+	return nil
+}
+
+// Reports whether every frame was captured, or the capture was closed
+func (c *FrameCapture) Done() bool {
+	return c.remaining <= 0
+}
+
+// Ends the capture early, flushing what was captured so far
+func (c *FrameCapture) Close() error {
+	c.remaining = 0
+	if c.y4m == nil {
+		return nil
+	}
+	w := c.y4m
+	c.y4m = nil
+	return w.close()
+}
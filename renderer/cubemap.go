@@ -0,0 +1,150 @@
+package renderer
+
+import (
+	"fmt"
+	"image"
+	"math"
+
+	"github.com/go-gl/gl/v4.3-core/gl"
+)
+
+// Cubemap faces, in the order GL numbers them
+const (
+	CubePositiveX = iota
+	CubeNegativeX
+	CubePositiveY
+	CubeNegativeY
+	CubePositiveZ
+	CubeNegativeZ
+)
+
+// Creates a cubemap from six square images of the same size, in the order
+// +X, -X, +Y, -Y, +Z, -Z (right, left, top, bottom, front, back)
+// Unlike 2D textures, cubemap faces are stored top row first, as they are on disk
+func NewCubemap(faces [6]string, opts TextureOptions) (*Texture, error) {
+	if err := opts.validate(); err != nil {
+		return nil, err
+	}
+	var imgs [6]*image.RGBA
+	for i, path := range faces {
+		im, err := readImage(path)
+		if err != nil {
+			return nil, err
+		}
+		if imgs[i], err = toRGBA(im); err != nil {
+			return nil, err
+		}
+		if b := imgs[i].Rect; b.Dx() != b.Dy() || b.Dx() != imgs[0].Rect.Dx() {
+			return nil, fmt.Errorf("cubemap face %s is %dx%d, faces must be square and of the same size", path, b.Dx(), b.Dy())
+		}
+	}
+	return newCubemap(imgs, opts), nil
+}
+
+// Creates a cubemap of size x size faces out of an equirectangular
+// (latitude/longitude) panorama
+func NewCubemapFromEquirect(path string, size int, opts TextureOptions) (*Texture, error) {
+	if err := opts.validate(); err != nil {
+		return nil, err
+	}
+	if size <= 0 {
+		return nil, fmt.Errorf("invalid cubemap size %d", size)
+	}
+	im, err := readImage(path)
+	if err != nil {
+		return nil, err
+	}
+	pano, err := toRGBA(im)
+	if err != nil {
+		return nil, err
+	}
+
+	var imgs [6]*image.RGBA
+	for face := range imgs {
+		imgs[face] = image.NewRGBA(image.Rect(0, 0, size, size))
+		for y := 0; y < size; y++ {
+			for x := 0; x < size; x++ {
+				s := 2*(float64(x)+0.5)/float64(size) - 1
+				t := 2*(float64(y)+0.5)/float64(size) - 1
+				dx, dy, dz := cubeDirection(face, s, t)
+				copy(imgs[face].Pix[y*imgs[face].Stride+x*4:], sampleEquirect(pano, dx, dy, dz))
+			}
+		}
+	}
+	t := newCubemap(imgs, opts)
+	t.filepath = path
+	return t, nil
+}
+
+// Returns the direction through the point (s, t) of a face, both in [-1, 1]
+// This is the inverse of the face selection table of the GL specification
+func cubeDirection(face int, s, t float64) (float64, float64, float64) {
+	var x, y, z float64
+	switch face {
+	case CubePositiveX:
+		x, y, z = 1, -t, -s
+	case CubeNegativeX:
+		x, y, z = -1, -t, s
+	case CubePositiveY:
+		x, y, z = s, 1, t
+	case CubeNegativeY:
+		x, y, z = s, -1, -t
+	case CubePositiveZ:
+		x, y, z = s, -t, 1
+	case CubeNegativeZ:
+		x, y, z = -s, -t, -1
+	}
+	l := math.Sqrt(x*x + y*y + z*z)
+	return x / l, y / l, z / l
+}
+
+// Returns the panorama pixel a direction points at
+// The center of the panorama looks down -Z, its top row is straight up
+func sampleEquirect(pano *image.RGBA, x, y, z float64) []uint8 {
+	w, h := pano.Rect.Dx(), pano.Rect.Dy()
+	u := 0.5 + math.Atan2(x, -z)/(2*math.Pi)
+	v := 0.5 - math.Asin(y)/math.Pi
+	px := int(u*float64(w)) % w
+	py := int(v * float64(h))
+	if py >= h {
+		py = h - 1
+	}
+	i := py*pano.Stride + px*4
+	return pano.Pix[i : i+4]
+}
+
+func newCubemap(faces [6]*image.RGBA, opts TextureOptions) *Texture {
+	size := int32(faces[0].Rect.Dx())
+	t := Texture{
+		target: gl.TEXTURE_CUBE_MAP,
+		Width:  size,
+		Height: size,
+	}
+
+	backend.GenTextures(1, &t.rendererID)
+	backend.BindTexture(gl.TEXTURE_CUBE_MAP, t.rendererID)
+
+	// Seams between faces are only hidden when filtering across them
+	backend.Enable(gl.TEXTURE_CUBE_MAP_SEAMLESS)
+	opts.apply(gl.TEXTURE_CUBE_MAP)
+	backend.TexParameteri(gl.TEXTURE_CUBE_MAP, gl.TEXTURE_WRAP_R, opts.WrapT)
+
+	for i, f := range faces {
+		backend.TexImage2D(gl.TEXTURE_CUBE_MAP_POSITIVE_X+uint32(i), 0, opts.internalFormat(), size, size, 0, gl.RGBA, gl.UNSIGNED_BYTE, gl.Ptr(f.Pix))
+	}
+	if opts.Mipmaps {
+		backend.GenerateMipmap(gl.TEXTURE_CUBE_MAP)
+	}
+	backend.BindTexture(gl.TEXTURE_CUBE_MAP, 0)
+	return &t
+}
+
+// Returns options suited to cubemaps: clamped so that faces don't bleed into each other
+func CubemapTextureOptions() TextureOptions {
+	return TextureOptions{
+		WrapS:     gl.CLAMP_TO_EDGE,
+		WrapT:     gl.CLAMP_TO_EDGE,
+		MinFilter: gl.LINEAR,
+		MagFilter: gl.LINEAR,
+	}
+}
@@ -0,0 +1,386 @@
+package renderer
+
+import (
+	"fmt"
+
+	"github.com/go-gl/gl/v4.3-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// Color attachments a framebuffer can have, the minimum GL guarantees
+const maxColorAttachments = 8
+
+// Pixel format and type of every supported attachment format, by internal format
+// Only needed to allocate storage, nothing is uploaded
+var attachmentFormats = map[int32]struct{ format, xtype uint32 }{
+	gl.RGBA8:          {gl.RGBA, gl.UNSIGNED_BYTE},
+	gl.SRGB8_ALPHA8:   {gl.RGBA, gl.UNSIGNED_BYTE},
+	gl.RGBA16F:        {gl.RGBA, gl.FLOAT},
+	gl.RGBA32F:        {gl.RGBA, gl.FLOAT},
+	gl.RGB16F:         {gl.RGB, gl.FLOAT},
+	gl.R11F_G11F_B10F: {gl.RGB, gl.FLOAT},
+	gl.RG16F:          {gl.RG, gl.FLOAT},
+	gl.R32F:           {gl.RED, gl.FLOAT},
+
+	gl.DEPTH_COMPONENT16:  {gl.DEPTH_COMPONENT, gl.FLOAT},
+	gl.DEPTH_COMPONENT24:  {gl.DEPTH_COMPONENT, gl.FLOAT},
+	gl.DEPTH_COMPONENT32F: {gl.DEPTH_COMPONENT, gl.FLOAT},
+	gl.DEPTH24_STENCIL8:   {gl.DEPTH_STENCIL, gl.UNSIGNED_INT_24_8},
+	gl.DEPTH32F_STENCIL8:  {gl.DEPTH_STENCIL, gl.FLOAT_32_UNSIGNED_INT_24_8_REV},
+}
+
+// Describes the attachments of a framebuffer
+type FramebufferSpec struct {
+	Width, Height int32
+	Colors        []int32 // Internal format of every color output, e.g. gl.RGBA8, or gl.RGBA16F for HDR
+	Depth         int32   // Internal format of the depth (and stencil) attachment, 0 for none
+	DepthTexture  bool    // Attach depth as a texture that can be sampled, e.g. for shadow maps
+}
+
+// Default spec of an offscreen color target with depth and stencil
+func DefaultFramebufferSpec(width, height int32) FramebufferSpec {
+	return FramebufferSpec{
+		Width:  width,
+		Height: height,
+		Colors: []int32{gl.RGBA8},
+		Depth:  gl.DEPTH24_STENCIL8,
+	}
+}
+
+func (spec FramebufferSpec) validate() error {
+	if spec.Width <= 0 || spec.Height <= 0 {
+		return fmt.Errorf("invalid framebuffer size %dx%d", spec.Width, spec.Height)
+	}
+	if len(spec.Colors) > maxColorAttachments {
+		return fmt.Errorf("%d color attachments, at most %d are supported", len(spec.Colors), maxColorAttachments)
+	}
+	if len(spec.Colors) == 0 && spec.Depth == 0 {
+		return fmt.Errorf("framebuffer without attachments")
+	}
+	for _, f := range spec.Colors {
+		if _, ok := attachmentFormats[f]; !ok || isDepthFormat(f) {
+			return fmt.Errorf("unsupported color attachment format 0x%X", f)
+		}
+	}
+	if spec.Depth != 0 && !isDepthFormat(spec.Depth) {
+		return fmt.Errorf("unsupported depth attachment format 0x%X", spec.Depth)
+	}
+	if spec.DepthTexture && spec.Depth == 0 {
+		return fmt.Errorf("depth texture without a depth format")
+	}
+	return nil
+}
+
+func isDepthFormat(f int32) bool {
+	switch f {
+	case gl.DEPTH_COMPONENT16, gl.DEPTH_COMPONENT24, gl.DEPTH_COMPONENT32F, gl.DEPTH24_STENCIL8, gl.DEPTH32F_STENCIL8:
+		return true
+	}
+	return false
+}
+
+func hasStencil(f int32) bool {
+	return f == gl.DEPTH24_STENCIL8 || f == gl.DEPTH32F_STENCIL8
+}
+
+// An offscreen render target
+// Its color outputs (and optionally its depth) are textures that can be sampled afterwards
+type Framebuffer struct {
+	rendererID uint32
+	spec       FramebufferSpec
+	colors     []*Texture
+	depth      *Texture // Set when the spec asks for a depth texture
+	depthRBO   uint32   // Depth renderbuffer otherwise, 0 when there is no depth
+
+	// What Bind replaced, restored by Unbind
+	prevFramebuffer int32
+	prevViewport    [4]int32
+}
+
+func NewFramebuffer(spec FramebufferSpec) (*Framebuffer, error) {
+	if err := spec.validate(); err != nil {
+		return nil, err
+	}
+	spec.Colors = append([]int32(nil), spec.Colors...)
+	fb := Framebuffer{spec: spec}
+
+	// Whatever is bound, e.g. a framebuffer being drawn to, stays bound afterwards
+	var prev int32
+	backend.GetIntegerv(gl.FRAMEBUFFER_BINDING, &prev)
+	backend.GenFramebuffers(1, &fb.rendererID)
+	backend.BindFramebuffer(gl.FRAMEBUFFER, fb.rendererID)
+	defer backend.BindFramebuffer(gl.FRAMEBUFFER, uint32(prev))
+
+	drawBuffers := make([]uint32, len(spec.Colors))
+	for i := range spec.Colors {
+		t := &Texture{target: gl.TEXTURE_2D}
+		backend.GenTextures(1, &t.rendererID)
+		backend.BindTexture(gl.TEXTURE_2D, t.rendererID)
+		backend.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.LINEAR)
+		backend.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.LINEAR)
+		backend.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_S, gl.CLAMP_TO_EDGE)
+		backend.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_T, gl.CLAMP_TO_EDGE)
+		backend.FramebufferTexture2D(gl.FRAMEBUFFER, gl.COLOR_ATTACHMENT0+uint32(i), gl.TEXTURE_2D, t.rendererID, 0)
+		fb.colors = append(fb.colors, t)
+		drawBuffers[i] = gl.COLOR_ATTACHMENT0 + uint32(i)
+	}
+	if len(drawBuffers) > 0 {
+		backend.DrawBuffers(int32(len(drawBuffers)), &drawBuffers[0])
+	} else {
+		// Depth only, e.g. a shadow map
+		backend.DrawBuffer(gl.NONE)
+		backend.ReadBuffer(gl.NONE)
+	}
+
+	attachment := uint32(gl.DEPTH_ATTACHMENT)
+	if hasStencil(spec.Depth) {
+		attachment = gl.DEPTH_STENCIL_ATTACHMENT
+	}
+	switch {
+	case spec.DepthTexture:
+		fb.depth = &Texture{target: gl.TEXTURE_2D}
+		backend.GenTextures(1, &fb.depth.rendererID)
+		backend.BindTexture(gl.TEXTURE_2D, fb.depth.rendererID)
+		backend.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.NEAREST)
+		backend.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.NEAREST)
+		backend.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_S, gl.CLAMP_TO_BORDER)
+		backend.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_T, gl.CLAMP_TO_BORDER)
+		// Outside the map nothing is in shadow
+		border := mgl32.Vec4{1, 1, 1, 1}
+		backend.TexParameterfv(gl.TEXTURE_2D, gl.TEXTURE_BORDER_COLOR, &border[0])
+		backend.FramebufferTexture2D(gl.FRAMEBUFFER, attachment, gl.TEXTURE_2D, fb.depth.rendererID, 0)
+	case spec.Depth != 0:
+		backend.GenRenderbuffers(1, &fb.depthRBO)
+		backend.BindRenderbuffer(gl.RENDERBUFFER, fb.depthRBO)
+		backend.FramebufferRenderbuffer(gl.FRAMEBUFFER, attachment, gl.RENDERBUFFER, fb.depthRBO)
+	}
+
+	if err := fb.allocate(spec.Width, spec.Height); err != nil {
+		fb.Delete()
+		return nil, err
+	}
+	return &fb, nil
+}
+
+// (Re)allocates the storage of every attachment and checks the framebuffer is complete
+// Attachments keep their GL objects, so textures handed out stay valid
+// The framebuffer must be bound
+func (fb *Framebuffer) allocate(width, height int32) error {
+	for i, t := range fb.colors {
+		f := attachmentFormats[fb.spec.Colors[i]]
+		backend.BindTexture(gl.TEXTURE_2D, t.rendererID)
+		backend.TexImage2D(gl.TEXTURE_2D, 0, fb.spec.Colors[i], width, height, 0, f.format, f.xtype, nil)
+		t.Width, t.Height = width, height
+	}
+	if fb.depth != nil {
+		f := attachmentFormats[fb.spec.Depth]
+		backend.BindTexture(gl.TEXTURE_2D, fb.depth.rendererID)
+		backend.TexImage2D(gl.TEXTURE_2D, 0, fb.spec.Depth, width, height, 0, f.format, f.xtype, nil)
+		fb.depth.Width, fb.depth.Height = width, height
+	}
+	backend.BindTexture(gl.TEXTURE_2D, 0)
+	if fb.depthRBO != 0 {
+		backend.BindRenderbuffer(gl.RENDERBUFFER, fb.depthRBO)
+		backend.RenderbufferStorage(gl.RENDERBUFFER, uint32(fb.spec.Depth), width, height)
+		backend.BindRenderbuffer(gl.RENDERBUFFER, 0)
+	}
+	fb.spec.Width, fb.spec.Height = width, height
+
+	if status := backend.CheckFramebufferStatus(gl.FRAMEBUFFER); status != gl.FRAMEBUFFER_COMPLETE {
+		return fmt.Errorf("framebuffer is incomplete: %s", framebufferStatus(status))
+	}
+	return nil
+}
+
+func framebufferStatus(status uint32) string {
+	switch status {
+	case gl.FRAMEBUFFER_UNDEFINED:
+		return "undefined"
+	case gl.FRAMEBUFFER_INCOMPLETE_ATTACHMENT:
+		return "incomplete attachment"
+	case gl.FRAMEBUFFER_INCOMPLETE_MISSING_ATTACHMENT:
+		return "missing attachment"
+	case gl.FRAMEBUFFER_INCOMPLETE_DRAW_BUFFER:
+		return "incomplete draw buffer"
+	case gl.FRAMEBUFFER_INCOMPLETE_READ_BUFFER:
+		return "incomplete read buffer"
+	case gl.FRAMEBUFFER_UNSUPPORTED:
+		return "unsupported format combination"
+	case gl.FRAMEBUFFER_INCOMPLETE_MULTISAMPLE:
+		return "incomplete multisample"
+	case gl.FRAMEBUFFER_INCOMPLETE_LAYER_TARGETS:
+		return "incomplete layer targets"
+	}
+	return fmt.Sprintf("status 0x%X", status)
+}
+
+// Changes the size of every attachment, e.g. when the window is resized
+// Their contents are lost
+func (fb *Framebuffer) Resize(width, height int32) error {
+	if width <= 0 || height <= 0 {
+		return fmt.Errorf("invalid framebuffer size %dx%d", width, height)
+	}
+	if width == fb.spec.Width && height == fb.spec.Height {
+		return nil
+	}
+	var prev int32
+	backend.GetIntegerv(gl.FRAMEBUFFER_BINDING, &prev)
+	backend.BindFramebuffer(gl.FRAMEBUFFER, fb.rendererID)
+	defer backend.BindFramebuffer(gl.FRAMEBUFFER, uint32(prev))
+	return fb.allocate(width, height)
+}
+
+// Redirects drawing to the framebuffer and sets the viewport to cover it
+func (fb *Framebuffer) Bind() {
+	backend.GetIntegerv(gl.FRAMEBUFFER_BINDING, &fb.prevFramebuffer)
+	backend.GetIntegerv(gl.VIEWPORT, &fb.prevViewport[0])
+	backend.BindFramebuffer(gl.FRAMEBUFFER, fb.rendererID)
+	backend.Viewport(0, 0, fb.spec.Width, fb.spec.Height)
+}
+
+// Goes back to the framebuffer and viewport that were in use before Bind
+func (fb *Framebuffer) Unbind() {
+	backend.BindFramebuffer(gl.FRAMEBUFFER, uint32(fb.prevFramebuffer))
+	v := fb.prevViewport
+	backend.Viewport(v[0], v[1], v[2], v[3])
+}
+
+// Clears every attachment, colors to the given color
+// The framebuffer must be bound
+func (fb *Framebuffer) Clear(color mgl32.Vec4) {
+	backend.ClearColor(color[0], color[1], color[2], color[3])
+	mask := uint32(gl.COLOR_BUFFER_BIT)
+	if fb.spec.Depth != 0 {
+		mask |= gl.DEPTH_BUFFER_BIT
+	}
+	if hasStencil(fb.spec.Depth) {
+		mask |= gl.STENCIL_BUFFER_BIT
+	}
+	backend.Clear(mask)
+}
+
+func (fb *Framebuffer) Width() int32  { return fb.spec.Width }
+func (fb *Framebuffer) Height() int32 { return fb.spec.Height }
+
+// Number of color outputs, fragment shaders write output i to attachment i
+func (fb *Framebuffer) ColorAttachments() int {
+	return len(fb.colors)
+}
+
+// Returns the texture of a color output
+func (fb *Framebuffer) ColorTexture(i int) (*Texture, error) {
+	if i < 0 || i >= len(fb.colors) {
+		return nil, fmt.Errorf("no such color attachment: %d", i)
+	}
+	return fb.colors[i], nil
+}
+
+// Returns the depth texture, nil unless the spec asked for one
+func (fb *Framebuffer) DepthTexture() *Texture {
+	return fb.depth
+}
+
+// Deletes the framebuffer along with its attachments
+func (fb *Framebuffer) Delete() {
+	fb.deleteTarget()
+	for _, t := range fb.colors {
+		t.Delete()
+	}
+	if fb.depth != nil {
+		fb.depth.Delete()
+	}
+}
+
+// Deletes the framebuffer but not its textures, which may still be in use
+func (fb *Framebuffer) deleteTarget() {
+	backend.DeleteFramebuffers(1, &fb.rendererID)
+	if fb.depthRBO != 0 {
+		backend.DeleteRenderbuffers(1, &fb.depthRBO)
+	}
+}
+
+// A framebuffer created through the renderer and the texture IDs of its attachments
+type framebufferEntry struct {
+	fb     *Framebuffer
+	colors []int
+	depth  int // NoTexture unless depth is a texture
+}
+
+// Creates a framebuffer and registers its textures, so materials can sample them like any other
+// The framebuffer holds a reference to each texture until DeleteFramebuffer
+// Returns an internal object ID
+func (r *Renderer) CreateFramebuffer(spec FramebufferSpec) (int, error) {
+	fb, err := NewFramebuffer(spec)
+	if err != nil {
+		return 0, err
+	}
+	fbID := len(r.framebuffers)
+	e := &framebufferEntry{fb: fb, depth: NoTexture}
+	for i, t := range fb.colors {
+		e.colors = append(e.colors, r.addTexture(fmt.Sprintf("framebuffer:%d:color%d", fbID, i), t))
+	}
+	if fb.depth != nil {
+		e.depth = r.addTexture(fmt.Sprintf("framebuffer:%d:depth", fbID), fb.depth)
+	}
+	r.framebuffers = append(r.framebuffers, e)
+	return fbID, nil
+}
+
+func (r *Renderer) framebuffer(fbID int) (*framebufferEntry, error) {
+	if fbID < 0 || fbID >= len(r.framebuffers) || r.framebuffers[fbID] == nil {
+		return nil, fmt.Errorf("no such framebuffer: %d", fbID)
+	}
+	return r.framebuffers[fbID], nil
+}
+
+// Returns a framebuffer created by CreateFramebuffer, e.g. to bind or resize it
+func (r *Renderer) Framebuffer(fbID int) (*Framebuffer, error) {
+	e, err := r.framebuffer(fbID)
+	if err != nil {
+		return nil, err
+	}
+	return e.fb, nil
+}
+
+// Returns the texture ID of a color output of a framebuffer
+// The ID stays valid across resizes
+func (r *Renderer) FramebufferTexture(fbID, attachment int) (int, error) {
+	e, err := r.framebuffer(fbID)
+	if err != nil {
+		return 0, err
+	}
+	if attachment < 0 || attachment >= len(e.colors) {
+		return 0, fmt.Errorf("framebuffer %d has no color attachment %d", fbID, attachment)
+	}
+	return e.colors[attachment], nil
+}
+
+// Returns the texture ID of the depth attachment of a framebuffer
+func (r *Renderer) FramebufferDepthTexture(fbID int) (int, error) {
+	e, err := r.framebuffer(fbID)
+	if err != nil {
+		return 0, err
+	}
+	if e.depth == NoTexture {
+		return 0, fmt.Errorf("framebuffer %d has no depth texture", fbID)
+	}
+	return e.depth, nil
+}
+
+// Deletes a framebuffer and gives back its references to its textures
+// Textures still used elsewhere live on until they are released there too
+func (r *Renderer) DeleteFramebuffer(fbID int) error {
+	e, err := r.framebuffer(fbID)
+	if err != nil {
+		return err
+	}
+	e.fb.deleteTarget()
+	for _, texID := range e.colors {
+		r.ReleaseTexture(texID)
+	}
+	if e.depth != NoTexture {
+		r.ReleaseTexture(e.depth)
+	}
+	r.framebuffers[fbID] = nil
+	return nil
+}
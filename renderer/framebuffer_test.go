@@ -0,0 +1,47 @@
+package renderer
+
+import "testing"
+
+// Creating, resizing or drawing into a framebuffer inside another one's
+// Bind/Unbind must leave the outer framebuffer bound
+func TestFramebufferKeepsBinding(t *testing.T) {
+	rec := useRecorder(t)
+	outer, err := NewFramebuffer(DefaultFramebufferSpec(64, 64))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rec.framebuffer != 0 {
+		t.Fatalf("NewFramebuffer left framebuffer %d bound", rec.framebuffer)
+	}
+
+	backend.Viewport(0, 0, 800, 600)
+	outer.Bind()
+	if rec.framebuffer != outer.rendererID || rec.viewport != [4]int32{0, 0, 64, 64} {
+		t.Fatalf("Bind left framebuffer %d and viewport %v", rec.framebuffer, rec.viewport)
+	}
+
+	inner, err := NewFramebuffer(DefaultFramebufferSpec(32, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rec.framebuffer != outer.rendererID {
+		t.Errorf("NewFramebuffer bound %d instead of restoring %d", rec.framebuffer, outer.rendererID)
+	}
+	if err := inner.Resize(16, 16); err != nil {
+		t.Fatal(err)
+	}
+	if rec.framebuffer != outer.rendererID {
+		t.Errorf("Resize bound %d instead of restoring %d", rec.framebuffer, outer.rendererID)
+	}
+
+	inner.Bind()
+	inner.Unbind()
+	if rec.framebuffer != outer.rendererID || rec.viewport != [4]int32{0, 0, 64, 64} {
+		t.Errorf("nested Unbind left framebuffer %d and viewport %v", rec.framebuffer, rec.viewport)
+	}
+
+	outer.Unbind()
+	if rec.framebuffer != 0 || rec.viewport != [4]int32{0, 0, 800, 600} {
+		t.Errorf("Unbind left framebuffer %d and viewport %v", rec.framebuffer, rec.viewport)
+	}
+}
@@ -0,0 +1,427 @@
+package renderer
+
+import (
+	"unsafe"
+
+	"github.com/go-gl/gl/v4.3-core/gl"
+)
+
+// Calls straight into OpenGL, the default backend
+// Needs a current context, like go-gl itself
+type glBackend struct{}
+
+func (glBackend) Enable(cap uint32) {
+	gl.Enable(cap)
+}
+
+func (glBackend) Disable(cap uint32) {
+	gl.Disable(cap)
+}
+
+func (glBackend) DepthFunc(xfunc uint32) {
+	gl.DepthFunc(xfunc)
+}
+
+func (glBackend) DepthMask(flag bool) {
+	gl.DepthMask(flag)
+}
+
+func (glBackend) BlendFunc(sfactor uint32, dfactor uint32) {
+	gl.BlendFunc(sfactor, dfactor)
+}
+
+func (glBackend) CullFace(mode uint32) {
+	gl.CullFace(mode)
+}
+
+func (glBackend) FrontFace(mode uint32) {
+	gl.FrontFace(mode)
+}
+
+func (glBackend) Viewport(x int32, y int32, width int32, height int32) {
+	gl.Viewport(x, y, width, height)
+}
+
+func (glBackend) ClearColor(r float32, g float32, b float32, a float32) {
+	gl.ClearColor(r, g, b, a)
+}
+
+func (glBackend) Clear(mask uint32) {
+	gl.Clear(mask)
+}
+
+func (glBackend) Finish() {
+	gl.Finish()
+}
+
+func (glBackend) PixelStorei(pname uint32, param int32) {
+	gl.PixelStorei(pname, param)
+}
+
+func (glBackend) GetIntegerv(pname uint32, data *int32) {
+	gl.GetIntegerv(pname, data)
+}
+
+func (glBackend) GetFloatv(pname uint32, data *float32) {
+	gl.GetFloatv(pname, data)
+}
+
+func (glBackend) DebugMessageCallback(callback gl.DebugProc, userParam unsafe.Pointer) {
+	gl.DebugMessageCallback(callback, userParam)
+}
+
+func (glBackend) GenBuffers(n int32, buffers *uint32) {
+	gl.GenBuffers(n, buffers)
+}
+
+func (glBackend) DeleteBuffers(n int32, buffers *uint32) {
+	gl.DeleteBuffers(n, buffers)
+}
+
+func (glBackend) BindBuffer(target uint32, buffer uint32) {
+	gl.BindBuffer(target, buffer)
+}
+
+func (glBackend) BindBufferBase(target uint32, index uint32, buffer uint32) {
+	gl.BindBufferBase(target, index, buffer)
+}
+
+func (glBackend) BufferData(target uint32, size int, data unsafe.Pointer, usage uint32) {
+	gl.BufferData(target, size, data, usage)
+}
+
+func (glBackend) BufferSubData(target uint32, offset int, size int, data unsafe.Pointer) {
+	gl.BufferSubData(target, offset, size, data)
+}
+
+func (glBackend) GenVertexArrays(n int32, arrays *uint32) {
+	gl.GenVertexArrays(n, arrays)
+}
+
+func (glBackend) DeleteVertexArrays(n int32, arrays *uint32) {
+	gl.DeleteVertexArrays(n, arrays)
+}
+
+func (glBackend) BindVertexArray(array uint32) {
+	gl.BindVertexArray(array)
+}
+
+func (glBackend) EnableVertexAttribArray(index uint32) {
+	gl.EnableVertexAttribArray(index)
+}
+
+func (glBackend) VertexAttribPointer(index uint32, size int32, xtype uint32, normalized bool, stride int32, pointer unsafe.Pointer) {
+	gl.VertexAttribPointer(index, size, xtype, normalized, stride, pointer)
+}
+
+func (glBackend) VertexAttribDivisor(index uint32, divisor uint32) {
+	gl.VertexAttribDivisor(index, divisor)
+}
+
+func (glBackend) DrawArrays(mode uint32, first int32, count int32) {
+	gl.DrawArrays(mode, first, count)
+}
+
+func (glBackend) DrawArraysInstanced(mode uint32, first int32, count int32, instancecount int32) {
+	gl.DrawArraysInstanced(mode, first, count, instancecount)
+}
+
+func (glBackend) DrawElements(mode uint32, count int32, xtype uint32, indices unsafe.Pointer) {
+	gl.DrawElements(mode, count, xtype, indices)
+}
+
+func (glBackend) DrawElementsInstanced(mode uint32, count int32, xtype uint32, indices unsafe.Pointer, instancecount int32) {
+	gl.DrawElementsInstanced(mode, count, xtype, indices, instancecount)
+}
+
+func (glBackend) GenTextures(n int32, textures *uint32) {
+	gl.GenTextures(n, textures)
+}
+
+func (glBackend) DeleteTextures(n int32, textures *uint32) {
+	gl.DeleteTextures(n, textures)
+}
+
+func (glBackend) ActiveTexture(texture uint32) {
+	gl.ActiveTexture(texture)
+}
+
+func (glBackend) BindTexture(target uint32, texture uint32) {
+	gl.BindTexture(target, texture)
+}
+
+func (glBackend) TexImage2D(target uint32, level int32, internalformat int32, width int32, height int32, border int32, format uint32, xtype uint32, pixels unsafe.Pointer) {
+	gl.TexImage2D(target, level, internalformat, width, height, border, format, xtype, pixels)
+}
+
+func (glBackend) TexImage3D(target uint32, level int32, internalformat int32, width int32, height int32, depth int32, border int32, format uint32, xtype uint32, pixels unsafe.Pointer) {
+	gl.TexImage3D(target, level, internalformat, width, height, depth, border, format, xtype, pixels)
+}
+
+func (glBackend) TexSubImage3D(target uint32, level int32, xoffset int32, yoffset int32, zoffset int32, width int32, height int32, depth int32, format uint32, xtype uint32, pixels unsafe.Pointer) {
+	gl.TexSubImage3D(target, level, xoffset, yoffset, zoffset, width, height, depth, format, xtype, pixels)
+}
+
+func (glBackend) TexParameteri(target uint32, pname uint32, param int32) {
+	gl.TexParameteri(target, pname, param)
+}
+
+func (glBackend) TexParameterf(target uint32, pname uint32, param float32) {
+	gl.TexParameterf(target, pname, param)
+}
+
+func (glBackend) TexParameterfv(target uint32, pname uint32, params *float32) {
+	gl.TexParameterfv(target, pname, params)
+}
+
+func (glBackend) GenerateMipmap(target uint32) {
+	gl.GenerateMipmap(target)
+}
+
+func (glBackend) GenFramebuffers(n int32, framebuffers *uint32) {
+	gl.GenFramebuffers(n, framebuffers)
+}
+
+func (glBackend) DeleteFramebuffers(n int32, framebuffers *uint32) {
+	gl.DeleteFramebuffers(n, framebuffers)
+}
+
+func (glBackend) BindFramebuffer(target uint32, framebuffer uint32) {
+	gl.BindFramebuffer(target, framebuffer)
+}
+
+func (glBackend) FramebufferTexture2D(target uint32, attachment uint32, textarget uint32, texture uint32, level int32) {
+	gl.FramebufferTexture2D(target, attachment, textarget, texture, level)
+}
+
+func (glBackend) GenRenderbuffers(n int32, renderbuffers *uint32) {
+	gl.GenRenderbuffers(n, renderbuffers)
+}
+
+func (glBackend) DeleteRenderbuffers(n int32, renderbuffers *uint32) {
+	gl.DeleteRenderbuffers(n, renderbuffers)
+}
+
+func (glBackend) BindRenderbuffer(target uint32, renderbuffer uint32) {
+	gl.BindRenderbuffer(target, renderbuffer)
+}
+
+func (glBackend) RenderbufferStorage(target uint32, internalformat uint32, width int32, height int32) {
+	gl.RenderbufferStorage(target, internalformat, width, height)
+}
+
+func (glBackend) FramebufferRenderbuffer(target uint32, attachment uint32, renderbuffertarget uint32, renderbuffer uint32) {
+	gl.FramebufferRenderbuffer(target, attachment, renderbuffertarget, renderbuffer)
+}
+
+func (glBackend) CheckFramebufferStatus(target uint32) uint32 {
+	return gl.CheckFramebufferStatus(target)
+}
+
+func (glBackend) DrawBuffers(n int32, bufs *uint32) {
+	gl.DrawBuffers(n, bufs)
+}
+
+func (glBackend) DrawBuffer(buf uint32) {
+	gl.DrawBuffer(buf)
+}
+
+func (glBackend) ReadBuffer(src uint32) {
+	gl.ReadBuffer(src)
+}
+
+func (glBackend) ReadPixels(x int32, y int32, width int32, height int32, format uint32, xtype uint32, pixels unsafe.Pointer) {
+	gl.ReadPixels(x, y, width, height, format, xtype, pixels)
+}
+
+func (glBackend) CreateShader(xtype uint32) uint32 {
+	return gl.CreateShader(xtype)
+}
+
+func (glBackend) ShaderSource(shader uint32, count int32, xstring **uint8, length *int32) {
+	gl.ShaderSource(shader, count, xstring, length)
+}
+
+func (glBackend) CompileShader(shader uint32) {
+	gl.CompileShader(shader)
+}
+
+func (glBackend) GetShaderiv(shader uint32, pname uint32, params *int32) {
+	gl.GetShaderiv(shader, pname, params)
+}
+
+func (glBackend) GetShaderInfoLog(shader uint32, bufSize int32, length *int32, infoLog *uint8) {
+	gl.GetShaderInfoLog(shader, bufSize, length, infoLog)
+}
+
+func (glBackend) DeleteShader(shader uint32) {
+	gl.DeleteShader(shader)
+}
+
+func (glBackend) CreateProgram() uint32 {
+	return gl.CreateProgram()
+}
+
+func (glBackend) AttachShader(program uint32, shader uint32) {
+	gl.AttachShader(program, shader)
+}
+
+func (glBackend) LinkProgram(program uint32) {
+	gl.LinkProgram(program)
+}
+
+func (glBackend) GetProgramiv(program uint32, pname uint32, params *int32) {
+	gl.GetProgramiv(program, pname, params)
+}
+
+func (glBackend) GetProgramInfoLog(program uint32, bufSize int32, length *int32, infoLog *uint8) {
+	gl.GetProgramInfoLog(program, bufSize, length, infoLog)
+}
+
+func (glBackend) UseProgram(program uint32) {
+	gl.UseProgram(program)
+}
+
+func (glBackend) DeleteProgram(program uint32) {
+	gl.DeleteProgram(program)
+}
+
+func (glBackend) GetActiveUniform(program uint32, index uint32, bufSize int32, length *int32, size *int32, xtype *uint32, name *uint8) {
+	gl.GetActiveUniform(program, index, bufSize, length, size, xtype, name)
+}
+
+func (glBackend) GetActiveAttrib(program uint32, index uint32, bufSize int32, length *int32, size *int32, xtype *uint32, name *uint8) {
+	gl.GetActiveAttrib(program, index, bufSize, length, size, xtype, name)
+}
+
+func (glBackend) GetAttribLocation(program uint32, name *uint8) int32 {
+	return gl.GetAttribLocation(program, name)
+}
+
+func (glBackend) GetUniformLocation(program uint32, name *uint8) int32 {
+	return gl.GetUniformLocation(program, name)
+}
+
+func (glBackend) GetUniformBlockIndex(program uint32, uniformBlockName *uint8) uint32 {
+	return gl.GetUniformBlockIndex(program, uniformBlockName)
+}
+
+func (glBackend) GetActiveUniformBlockName(program uint32, uniformBlockIndex uint32, bufSize int32, length *int32, uniformBlockName *uint8) {
+	gl.GetActiveUniformBlockName(program, uniformBlockIndex, bufSize, length, uniformBlockName)
+}
+
+func (glBackend) GetActiveUniformBlockiv(program uint32, uniformBlockIndex uint32, pname uint32, params *int32) {
+	gl.GetActiveUniformBlockiv(program, uniformBlockIndex, pname, params)
+}
+
+func (glBackend) UniformBlockBinding(program uint32, uniformBlockIndex uint32, uniformBlockBinding uint32) {
+	gl.UniformBlockBinding(program, uniformBlockIndex, uniformBlockBinding)
+}
+
+func (glBackend) Uniform1f(location int32, v0 float32) {
+	gl.Uniform1f(location, v0)
+}
+
+func (glBackend) Uniform2f(location int32, v0 float32, v1 float32) {
+	gl.Uniform2f(location, v0, v1)
+}
+
+func (glBackend) Uniform3f(location int32, v0 float32, v1 float32, v2 float32) {
+	gl.Uniform3f(location, v0, v1, v2)
+}
+
+func (glBackend) Uniform4f(location int32, v0 float32, v1 float32, v2 float32, v3 float32) {
+	gl.Uniform4f(location, v0, v1, v2, v3)
+}
+
+func (glBackend) Uniform1i(location int32, v0 int32) {
+	gl.Uniform1i(location, v0)
+}
+
+func (glBackend) Uniform2i(location int32, v0 int32, v1 int32) {
+	gl.Uniform2i(location, v0, v1)
+}
+
+func (glBackend) Uniform3i(location int32, v0 int32, v1 int32, v2 int32) {
+	gl.Uniform3i(location, v0, v1, v2)
+}
+
+func (glBackend) Uniform4i(location int32, v0 int32, v1 int32, v2 int32, v3 int32) {
+	gl.Uniform4i(location, v0, v1, v2, v3)
+}
+
+func (glBackend) Uniform1ui(location int32, v0 uint32) {
+	gl.Uniform1ui(location, v0)
+}
+
+func (glBackend) Uniform2ui(location int32, v0 uint32, v1 uint32) {
+	gl.Uniform2ui(location, v0, v1)
+}
+
+func (glBackend) Uniform3ui(location int32, v0 uint32, v1 uint32, v2 uint32) {
+	gl.Uniform3ui(location, v0, v1, v2)
+}
+
+func (glBackend) Uniform4ui(location int32, v0 uint32, v1 uint32, v2 uint32, v3 uint32) {
+	gl.Uniform4ui(location, v0, v1, v2, v3)
+}
+
+func (glBackend) Uniform1fv(location int32, count int32, value *float32) {
+	gl.Uniform1fv(location, count, value)
+}
+
+func (glBackend) Uniform2fv(location int32, count int32, value *float32) {
+	gl.Uniform2fv(location, count, value)
+}
+
+func (glBackend) Uniform3fv(location int32, count int32, value *float32) {
+	gl.Uniform3fv(location, count, value)
+}
+
+func (glBackend) Uniform4fv(location int32, count int32, value *float32) {
+	gl.Uniform4fv(location, count, value)
+}
+
+func (glBackend) Uniform1iv(location int32, count int32, value *int32) {
+	gl.Uniform1iv(location, count, value)
+}
+
+func (glBackend) Uniform2iv(location int32, count int32, value *int32) {
+	gl.Uniform2iv(location, count, value)
+}
+
+func (glBackend) Uniform3iv(location int32, count int32, value *int32) {
+	gl.Uniform3iv(location, count, value)
+}
+
+func (glBackend) Uniform4iv(location int32, count int32, value *int32) {
+	gl.Uniform4iv(location, count, value)
+}
+
+func (glBackend) Uniform1uiv(location int32, count int32, value *uint32) {
+	gl.Uniform1uiv(location, count, value)
+}
+
+func (glBackend) Uniform2uiv(location int32, count int32, value *uint32) {
+	gl.Uniform2uiv(location, count, value)
+}
+
+func (glBackend) Uniform3uiv(location int32, count int32, value *uint32) {
+	gl.Uniform3uiv(location, count, value)
+}
+
+func (glBackend) Uniform4uiv(location int32, count int32, value *uint32) {
+	gl.Uniform4uiv(location, count, value)
+}
+
+func (glBackend) UniformMatrix2fv(location int32, count int32, transpose bool, value *float32) {
+	gl.UniformMatrix2fv(location, count, transpose, value)
+}
+
+func (glBackend) UniformMatrix3fv(location int32, count int32, transpose bool, value *float32) {
+	gl.UniformMatrix3fv(location, count, transpose, value)
+}
+
+func (glBackend) UniformMatrix4fv(location int32, count int32, transpose bool, value *float32) {
+	gl.UniformMatrix4fv(location, count, transpose, value)
+}
@@ -0,0 +1,43 @@
+package renderer
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// How often ReloadPrograms looks at the shader files
+const shaderPollInterval = 500 * time.Millisecond
+
+// Recompiles every program whose shader files changed on disk
+// Meant to be called between frames, files are only checked every shaderPollInterval
+// Programs that fail to compile keep running their last good version,
+// the returned error holds the compile logs of all of them
+func (r *Renderer) ReloadPrograms() error {
+	if time.Since(r.lastShaderPoll) < shaderPollInterval {
+		return nil
+	}
+	r.lastShaderPoll = time.Now()
+
+	var errs []string
+	for name, id := range r.programNames {
+		s := r.Programs[id]
+		if !s.Changed() {
+			continue
+		}
+		if err := s.Reload(); err != nil {
+			errs = append(errs, fmt.Sprintf("program %q: %v", name, err))
+			continue
+		}
+		bindUniformBlocks(s)
+		for key := range r.meshChecks {
+			if key[0] == id {
+				delete(r.meshChecks, key)
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("could not reload shaders:\n%s", strings.Join(errs, "\n"))
+	}
+	return nil
+}
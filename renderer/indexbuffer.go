@@ -4,7 +4,6 @@ import (
 	"github.com/go-gl/gl/v4.3-core/gl"
 )
 
-// NOTE: This is unused currently
 type IndexBuffer struct {
 	rendererID uint32 // A private ID for the object (e.g. OpenGL object ID)
 	count      int    // Count of indices
@@ -12,20 +11,25 @@ type IndexBuffer struct {
 
 func NewIndexBuffer(indices []uint32) *IndexBuffer {
 	ib := IndexBuffer{count: len(indices)}
-	gl.GenBuffers(1, &ib.rendererID)
-	gl.BindBuffer(gl.ELEMENT_ARRAY_BUFFER, ib.rendererID)
-	gl.BufferData(gl.ELEMENT_ARRAY_BUFFER, ib.count*sizes[UINT32], gl.Ptr(indices), gl.STATIC_DRAW)
+	backend.GenBuffers(1, &ib.rendererID)
+	backend.BindBuffer(gl.ELEMENT_ARRAY_BUFFER, ib.rendererID)
+	backend.BufferData(gl.ELEMENT_ARRAY_BUFFER, ib.count*sizes[UINT32], gl.Ptr(indices), gl.STATIC_DRAW)
 	return &ib
 }
 
 func (ib *IndexBuffer) Bind() {
-	gl.BindBuffer(gl.ELEMENT_ARRAY_BUFFER, ib.rendererID)
+	backend.BindBuffer(gl.ELEMENT_ARRAY_BUFFER, ib.rendererID)
 }
 
 func (ib *IndexBuffer) Unbind() {
-	gl.BindBuffer(gl.ELEMENT_ARRAY_BUFFER, 0)
+	backend.BindBuffer(gl.ELEMENT_ARRAY_BUFFER, 0)
 }
 
 func (ib *IndexBuffer) Delete() {
-	gl.DeleteBuffers(1, &ib.rendererID)
+	backend.DeleteBuffers(1, &ib.rendererID)
+}
+
+// Returns the number of indices held by the buffer
+func (ib *IndexBuffer) Count() int32 {
+	return int32(ib.count)
 }
@@ -0,0 +1,57 @@
+package renderer
+
+import (
+	"github.com/go-gl/gl/v4.3-core/gl"
+)
+
+// NoTexture is the texture ID of an empty texture slot
+const NoTexture = -1
+
+// RenderState holds the fixed-function state a draw needs
+type RenderState struct {
+	DepthTest  bool
+	DepthWrite bool
+	Blend      bool // Alpha blending with SRC_ALPHA, ONE_MINUS_SRC_ALPHA
+	CullFace   bool // Back-face culling, counter-clockwise front faces
+}
+
+// Returns the state of an opaque, depth-tested object
+func DefaultRenderState() RenderState {
+	return RenderState{
+		DepthTest:  true,
+		DepthWrite: true,
+	}
+}
+
+func (rs RenderState) apply() {
+	setCapability(gl.DEPTH_TEST, rs.DepthTest)
+	backend.DepthMask(rs.DepthWrite)
+	setCapability(gl.BLEND, rs.Blend)
+	if rs.Blend {
+		backend.BlendFunc(gl.SRC_ALPHA, gl.ONE_MINUS_SRC_ALPHA)
+	}
+	setCapability(gl.CULL_FACE, rs.CullFace)
+	if rs.CullFace {
+		backend.CullFace(gl.BACK)
+		backend.FrontFace(gl.CCW)
+	}
+}
+
+func setCapability(capability uint32, enabled bool) {
+	if enabled {
+		backend.Enable(capability)
+	} else {
+		backend.Disable(capability)
+	}
+}
+
+// Material is anything that knows which program to draw with
+// and how to set up its uniforms and textures
+// The program is already bound when Apply is called
+// TextureID is the main texture, used to sort the render queue
+type Material interface {
+	ProgramID() int
+	TextureID() int
+	RenderState() RenderState
+	Apply(r *Renderer, s *Shader)
+}
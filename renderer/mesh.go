@@ -0,0 +1,81 @@
+package renderer
+
+import (
+	"fmt"
+)
+
+// Mesh is a reference-counted handle to uploaded vertex data
+// Every node drawing a mesh holds a reference, so they all share its GPU buffers
+type Mesh struct {
+	Name  string // Empty for anonymous meshes, which can't be looked up
+	VaoID int    // Internal VAO ID, as accepted by Draw
+	refs  int
+}
+
+// Uploads vertex data as a mesh
+// indices may be nil for non-indexed data, a nil layout means DefaultLayout.
+// A named mesh can be fetched by other users through Mesh.
+// The caller holds the first reference
+func (r *Renderer) LoadMesh(name string, vertices []float32, indices []uint32, vbl *VertexBufferLayout) (*Mesh, error) {
+	if _, ok := r.meshes[name]; ok && name != "" {
+		return nil, fmt.Errorf("a mesh named %q is already loaded", name)
+	}
+	if vbl == nil {
+		vbl = DefaultLayout()
+	}
+	vaoID, err := r.LoadMeshData(vertices, indices, vbl)
+	if err != nil {
+		return nil, err
+	}
+	m := &Mesh{Name: name, VaoID: vaoID, refs: 1}
+	if name != "" {
+		r.meshes[name] = m
+	}
+	return m, nil
+}
+
+// Finds a loaded mesh by name and takes a reference to it
+func (r *Renderer) Mesh(name string) (*Mesh, error) {
+	m, ok := r.meshes[name]
+	if !ok {
+		return nil, fmt.Errorf("Could not find a mesh by that name: %q", name)
+	}
+	m.refs++
+	return m, nil
+}
+
+// Returns the names of all loaded named meshes
+func (r *Renderer) MeshNames() []string {
+	names := make([]string, 0, len(r.meshes))
+	for name := range r.meshes {
+		names = append(names, name)
+	}
+	return names
+}
+
+// Takes another reference to the mesh
+// This lets a loaded mesh be used wherever raw geometry is accepted (see scene.Geometry)
+func (m *Mesh) Acquire(r *Renderer) (*Mesh, error) {
+	if m.refs <= 0 {
+		return nil, fmt.Errorf("mesh %q was already released", m.Name)
+	}
+	m.refs++
+	return m, nil
+}
+
+// Drops a reference to the mesh
+// Its GPU buffers are deleted along with the last reference
+func (r *Renderer) ReleaseMesh(m *Mesh) {
+	if m.refs <= 0 {
+		return
+	}
+	m.refs--
+	if m.refs > 0 {
+		return
+	}
+	r.vaos[m.VaoID].Delete()
+	r.vaos[m.VaoID] = nil
+	if r.meshes[m.Name] == m {
+		delete(r.meshes, m.Name)
+	}
+}
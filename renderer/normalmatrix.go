@@ -0,0 +1,21 @@
+package renderer
+
+import (
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// Returns the matrix transforming normals along with a model matrix,
+// the inverse transpose of its upper 3x3
+// Unlike the model matrix it keeps normals perpendicular under non-uniform scaling
+func NormalMatrix(model mgl32.Mat4) mgl32.Mat3 {
+	return model.Mat3().Inv().Transpose()
+}
+
+// Returns the normal matrix of every model matrix
+func NormalMatrices(models []mgl32.Mat4) []mgl32.Mat3 {
+	normals := make([]mgl32.Mat3, len(models))
+	for i, m := range models {
+		normals[i] = NormalMatrix(m)
+	}
+	return normals
+}
@@ -0,0 +1,152 @@
+package renderer
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// A preprocessed shader, ready to be compiled
+type shaderSource struct {
+	text     string
+	files    []string             // Every file read, #line directives refer to them by index
+	modTimes map[string]time.Time // Modification time of every file, as read
+}
+
+var includeRe = regexp.MustCompile(`^\s*#\s*include\s+"([^"]+)"\s*$`)
+var versionRe = regexp.MustCompile(`^\s*#\s*version\b`)
+
+// Reads a shader and everything it includes
+// #include "file" is resolved relative to the including file, every file is only included once
+// The defines are injected right after #version, e.g. {"NR_LIGHTS": "4", "INSTANCED": ""}
+// #line directives are emitted around every include so that compile
+// errors can be mapped back to the original files, see remapLog
+func preprocess(path string, defines map[string]string) (*shaderSource, error) {
+	src := &shaderSource{modTimes: make(map[string]time.Time)}
+	var out bytes.Buffer
+	if err := src.include(&out, path, defines, nil); err != nil {
+		return src, err
+	}
+	src.text = out.String()
+	return src, nil
+}
+
+// Appends a file to out
+// stack holds the files currently being included, to report include cycles
+func (src *shaderSource) include(out *bytes.Buffer, path string, defines map[string]string, stack []string) error {
+	path = filepath.Clean(path)
+	for _, p := range stack {
+		if p == path {
+			return fmt.Errorf("include cycle: %s -> %s", strings.Join(stack, " -> "), path)
+		}
+	}
+	for _, f := range src.files {
+		if f == path {
+			return nil
+		}
+	}
+
+	fi, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	src.modTimes[path] = fi.ModTime()
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	fileIdx := len(src.files)
+	src.files = append(src.files, path)
+	if len(stack) > 0 {
+		fmt.Fprintf(out, "#line 1 %d\n", fileIdx)
+	}
+	stack = append(stack, path)
+
+	// Only the top file may (and must) declare a version, defines go right after it
+	root := len(stack) == 1
+	versioned := false
+
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	for line := 1; sc.Scan(); line++ {
+		text := sc.Text()
+
+		if versionRe.MatchString(text) {
+			if !root {
+				return fmt.Errorf("%s:%d: #version in an included file", path, line)
+			}
+			out.WriteString(text + "\n")
+			writeDefines(out, defines)
+			fmt.Fprintf(out, "#line %d %d\n", line+1, fileIdx)
+			versioned = true
+			continue
+		}
+		if root && !versioned && strings.TrimSpace(text) != "" && !strings.HasPrefix(strings.TrimSpace(text), "//") {
+			return fmt.Errorf("%s:%d: expected #version first", path, line)
+		}
+
+		m := includeRe.FindStringSubmatch(text)
+		if m == nil {
+			out.WriteString(text + "\n")
+			continue
+		}
+		incPath := filepath.Join(filepath.Dir(path), filepath.FromSlash(m[1]))
+		if err := src.include(out, incPath, defines, stack); err != nil {
+			if os.IsNotExist(err) {
+				return fmt.Errorf("%s:%d: could not include %q", path, line, m[1])
+			}
+			return err
+		}
+		fmt.Fprintf(out, "#line %d %d\n", line+1, fileIdx)
+	}
+	if err := sc.Err(); err != nil {
+		return err
+	}
+	if root && !versioned {
+		return fmt.Errorf("%s: missing #version", path)
+	}
+	return nil
+}
+
+// Writes the defines, sorted so that the output is stable
+func writeDefines(out *bytes.Buffer, defines map[string]string) {
+	names := make([]string, 0, len(defines))
+	for name := range defines {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if v := defines[name]; v != "" {
+			fmt.Fprintf(out, "#define %s %s\n", name, v)
+		} else {
+			fmt.Fprintf(out, "#define %s\n", name)
+		}
+	}
+}
+
+// Matches the file and line drivers prefix their messages with:
+// "0:12(3): error" (Mesa), "0(12) : error" (NVIDIA), "ERROR: 0:12: " (AMD, Intel)
+var logLocationRe = regexp.MustCompile(`(?m)^(ERROR: |WARNING: )?(\d+)(?::(\d+)|\((\d+)\))`)
+
+// Replaces the source string numbers of a compile log with file names
+func (src *shaderSource) remapLog(log string) string {
+	return logLocationRe.ReplaceAllStringFunc(log, func(loc string) string {
+		m := logLocationRe.FindStringSubmatch(loc)
+		idx, err := strconv.Atoi(m[2])
+		if err != nil || idx >= len(src.files) {
+			return loc
+		}
+		line := m[3]
+		if line == "" {
+			line = m[4]
+		}
+		return fmt.Sprintf("%s%s:%s", m[1], src.files[idx], line)
+	})
+}
@@ -0,0 +1,130 @@
+package renderer
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// Writes the files into a temporary directory and returns it
+func shaderDir(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, text := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(text), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestPreprocessDefines(t *testing.T) {
+	dir := shaderDir(t, map[string]string{
+		"main.glsl": "// A comment may come first\n\n#version 430 core\nvoid main() {}\n",
+	})
+	src, err := preprocess(filepath.Join(dir, "main.glsl"), map[string]string{"NR_LIGHTS": "4", "INSTANCED": ""})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "// A comment may come first\n\n#version 430 core\n#define INSTANCED\n#define NR_LIGHTS 4\n#line 4 0\nvoid main() {}\n"
+	if src.text != want {
+		t.Errorf("got\n%s\nwant\n%s", src.text, want)
+	}
+}
+
+func TestPreprocessIncludes(t *testing.T) {
+	dir := shaderDir(t, map[string]string{
+		"main.glsl":   "#version 430 core\n#include \"common.glsl\"\n#include \"lights.glsl\"\n#include \"common.glsl\"\nvoid main() {}\n",
+		"common.glsl": "float common;\n",
+		"lights.glsl": "#include \"common.glsl\"\nfloat lights;\n",
+	})
+	main := filepath.Join(dir, "main.glsl")
+	src, err := preprocess(main, map[string]string{"A": "1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Every file is included once, #line maps each part back to its file
+	want := strings.Join([]string{
+		"#version 430 core",
+		"#define A 1",
+		"#line 2 0",
+		"#line 1 1",
+		"float common;",
+		"#line 3 0",
+		"#line 1 2",
+		"#line 2 2",
+		"float lights;",
+		"#line 4 0",
+		"#line 5 0",
+		"void main() {}",
+		"",
+	}, "\n")
+	if src.text != want {
+		t.Errorf("got\n%s\nwant\n%s", src.text, want)
+	}
+	files := []string{main, filepath.Join(dir, "common.glsl"), filepath.Join(dir, "lights.glsl")}
+	if strings.Join(src.files, ",") != strings.Join(files, ",") {
+		t.Errorf("got files %v, want %v", src.files, files)
+	}
+	if len(src.modTimes) != 3 {
+		t.Errorf("got %d modification times, want 3", len(src.modTimes))
+	}
+}
+
+func TestPreprocessErrors(t *testing.T) {
+	dir := shaderDir(t, map[string]string{
+		"cycle.glsl":     "#version 430 core\n#include \"a.glsl\"\n",
+		"a.glsl":         "#include \"b.glsl\"\n",
+		"b.glsl":         "#include \"a.glsl\"\n",
+		"self.glsl":      "#version 430 core\n#include \"self.glsl\"\n",
+		"missing.glsl":   "#version 430 core\n\n#include \"nope.glsl\"\n",
+		"nested.glsl":    "#version 430 core\n#include \"version.glsl\"\n",
+		"version.glsl":   "#version 430 core\n",
+		"late.glsl":      "float x;\n#version 430 core\n",
+		"noversion.glsl": "// nothing here\n",
+	})
+	p := func(name string) string { return filepath.Join(dir, name) }
+
+	tests := []struct {
+		file, err string
+	}{
+		{"cycle.glsl", "include cycle: " + p("cycle.glsl") + " -> " + p("a.glsl") + " -> " + p("b.glsl") + " -> " + p("a.glsl")},
+		{"self.glsl", "include cycle: " + p("self.glsl") + " -> " + p("self.glsl")},
+		{"missing.glsl", p("missing.glsl") + `:3: could not include "nope.glsl"`},
+		{"nested.glsl", p("version.glsl") + ":1: #version in an included file"},
+		{"late.glsl", p("late.glsl") + ":1: expected #version first"},
+		{"noversion.glsl", p("noversion.glsl") + ": missing #version"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			_, err := preprocess(p(tt.file), nil)
+			if err == nil || err.Error() != tt.err {
+				t.Errorf("got error %v, want %q", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestRemapLog(t *testing.T) {
+	src := &shaderSource{files: []string{"phong.glsl", "lighting.glsl"}}
+	tests := []struct {
+		name, log, want string
+	}{
+		{"mesa", "0:12(3): error: syntax error", "phong.glsl:12(3): error: syntax error"},
+		{"nvidia", "1(7) : error C0000: syntax error", "lighting.glsl:7 : error C0000: syntax error"},
+		{"amd", "ERROR: 1:5: 'x' : undeclared identifier", "ERROR: lighting.glsl:5: 'x' : undeclared identifier"},
+		{"warning", "WARNING: 0:2: extension not supported", "WARNING: phong.glsl:2: extension not supported"},
+		{"unknown file", "2:4(1): error: x", "2:4(1): error: x"},
+		{"multiple lines", "0:1(1): error: a\n1:2(1): error: b", "phong.glsl:1(1): error: a\nlighting.glsl:2(1): error: b"},
+		{"no location", "error: too many uniforms", "error: too many uniforms"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := src.remapLog(tt.log); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
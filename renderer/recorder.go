@@ -0,0 +1,663 @@
+package renderer
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unsafe"
+
+	"github.com/go-gl/gl/v4.3-core/gl"
+)
+
+// A GL call as seen by a Recorder
+type Command struct {
+	Name    string        // Function without the gl prefix, e.g. "DrawArrays"
+	Args    []interface{} // Value arguments, pointer arguments are left out unless noted below
+	Uniform string        // Uniform a Uniform* call sets, see Recorder.UniformSets
+}
+
+func (c Command) String() string {
+	args := make([]string, 0, len(c.Args)+1)
+	if c.Uniform != "" {
+		args = append(args, c.Uniform)
+	}
+	for _, a := range c.Args {
+		args = append(args, fmt.Sprint(a))
+	}
+	return c.Name + "(" + strings.Join(args, ", ") + ")"
+}
+
+// A Backend that needs no GPU: it logs every call and makes up plausible results
+// Objects get increasing IDs, shaders always compile and link, framebuffers are complete,
+// and every program reports Uniforms as its active uniforms.
+// Besides the value arguments, commands hold the IDs Gen* calls handed out,
+// the data of BufferData and BufferSubData (nil when there was none),
+// and the values of Uniform*v, DrawBuffers and TexParameterfv calls
+type Recorder struct {
+	Uniforms []Uniform // Locations are assigned in order, Location is ignored
+
+	commands    []Command
+	lastID      uint32
+	viewport    [4]int32
+	framebuffer uint32 // Bound to FRAMEBUFFER
+}
+
+var _ Backend = (*Recorder)(nil)
+
+func NewRecorder(uniforms ...Uniform) *Recorder {
+	return &Recorder{Uniforms: uniforms}
+}
+
+// Returns every call so far, in order
+func (rec *Recorder) Commands() []Command {
+	return append([]Command(nil), rec.commands...)
+}
+
+// Returns the calls to one function, e.g. "DrawArrays"
+func (rec *Recorder) Calls(name string) []Command {
+	var cmds []Command
+	for _, c := range rec.commands {
+		if c.Name == name {
+			cmds = append(cmds, c)
+		}
+	}
+	return cmds
+}
+
+// Returns the calls setting a uniform
+// Calls starting at an array's first element go by the array's name, later elements like "lights[2]"
+func (rec *Recorder) UniformSets(uniform string) []Command {
+	var cmds []Command
+	for _, c := range rec.commands {
+		if c.Uniform == uniform {
+			cmds = append(cmds, c)
+		}
+	}
+	return cmds
+}
+
+// Forgets the calls so far, objects keep their IDs
+func (rec *Recorder) Reset() {
+	rec.commands = nil
+}
+
+func (rec *Recorder) record(name string, args ...interface{}) {
+	rec.commands = append(rec.commands, Command{Name: name, Args: args})
+}
+
+func (rec *Recorder) uniform(name string, location int32, args ...interface{}) {
+	rec.commands = append(rec.commands, Command{Name: name, Args: args, Uniform: rec.uniformAt(location)})
+}
+
+// Array uniforms take one location per element, like GL does
+func uniformSize(u Uniform) int32 {
+	if u.Size > 1 {
+		return u.Size
+	}
+	return 1
+}
+
+// Returns the name of the uniform at a location, "" if there is none
+func (rec *Recorder) uniformAt(location int32) string {
+	var next int32
+	for _, u := range rec.Uniforms {
+		size := uniformSize(u)
+		if location >= next && location < next+size {
+			if location > next {
+				return fmt.Sprintf("%s[%d]", u.Name, location-next)
+			}
+			return u.Name
+		}
+		next += size
+	}
+	return ""
+}
+
+// Returns the location of a uniform or an array element, -1 if there is none
+func (rec *Recorder) uniformLocation(name string) int32 {
+	var next int32
+	for _, u := range rec.Uniforms {
+		if name == u.Name || name == u.Name+"[0]" {
+			return next
+		}
+		if idx := strings.TrimPrefix(name, u.Name+"["); idx != name && strings.HasSuffix(idx, "]") {
+			i, err := strconv.Atoi(strings.TrimSuffix(idx, "]"))
+			if err == nil && i >= 0 && int32(i) < uniformSize(u) {
+				return next + int32(i)
+			}
+		}
+		next += uniformSize(u)
+	}
+	return -1
+}
+
+// Name GetActiveUniform reports, arrays end in [0] like GL reports them
+func activeUniformName(u Uniform) string {
+	if u.Size > 1 {
+		return u.Name + "[0]"
+	}
+	return u.Name
+}
+
+func (rec *Recorder) nextIDs(name string, n int32, ids *uint32) {
+	out := uint32s(ids, int(n))
+	for i := range out {
+		rec.lastID++
+		out[i] = rec.lastID
+	}
+	rec.record(name, n, append([]uint32(nil), out...))
+}
+
+// Views GL array arguments as slices
+// The slices alias the caller's memory, copy them before keeping them around
+func uint32s(p *uint32, n int) []uint32 {
+	if p == nil || n <= 0 {
+		return nil
+	}
+	return (*[1 << 28]uint32)(unsafe.Pointer(p))[:n:n]
+}
+
+func int32s(p *int32, n int) []int32 {
+	if p == nil || n <= 0 {
+		return nil
+	}
+	return (*[1 << 28]int32)(unsafe.Pointer(p))[:n:n]
+}
+
+func float32s(p *float32, n int) []float32 {
+	if p == nil || n <= 0 {
+		return nil
+	}
+	return (*[1 << 28]float32)(unsafe.Pointer(p))[:n:n]
+}
+
+func bytesAt(p unsafe.Pointer, n int) []byte {
+	if p == nil || n <= 0 {
+		return nil
+	}
+	return append([]byte(nil), (*[1 << 30]byte)(p)[:n:n]...)
+}
+
+// Writes a NUL terminated name the way glGetActive* functions do
+func writeName(name string, bufSize int32, length *int32, buf *uint8) {
+	if bufSize <= 0 {
+		return
+	}
+	b := (*[1 << 28]uint8)(unsafe.Pointer(buf))[:bufSize:bufSize]
+	n := copy(b[:bufSize-1], name)
+	b[n] = 0
+	if length != nil {
+		*length = int32(n)
+	}
+}
+
+func (rec *Recorder) Viewport(x int32, y int32, width int32, height int32) {
+	rec.viewport = [4]int32{x, y, width, height}
+	rec.record("Viewport", x, y, width, height)
+}
+
+// Reports the last viewport and framebuffer, everything else is 0
+func (rec *Recorder) GetIntegerv(pname uint32, data *int32) {
+	rec.record("GetIntegerv", pname)
+	switch pname {
+	case gl.VIEWPORT:
+		copy(int32s(data, 4), rec.viewport[:])
+	case gl.FRAMEBUFFER_BINDING:
+		*data = int32(rec.framebuffer)
+	default:
+		*data = 0
+	}
+}
+
+func (rec *Recorder) GetFloatv(pname uint32, data *float32) {
+	rec.record("GetFloatv", pname)
+	*data = 0
+}
+
+// The callback is never called, there are no driver messages to report
+func (rec *Recorder) DebugMessageCallback(callback gl.DebugProc, userParam unsafe.Pointer) {
+	rec.record("DebugMessageCallback")
+}
+
+func (rec *Recorder) GenBuffers(n int32, buffers *uint32) {
+	rec.nextIDs("GenBuffers", n, buffers)
+}
+
+func (rec *Recorder) GenVertexArrays(n int32, arrays *uint32) {
+	rec.nextIDs("GenVertexArrays", n, arrays)
+}
+
+func (rec *Recorder) GenTextures(n int32, textures *uint32) {
+	rec.nextIDs("GenTextures", n, textures)
+}
+
+func (rec *Recorder) GenFramebuffers(n int32, framebuffers *uint32) {
+	rec.nextIDs("GenFramebuffers", n, framebuffers)
+}
+
+func (rec *Recorder) GenRenderbuffers(n int32, renderbuffers *uint32) {
+	rec.nextIDs("GenRenderbuffers", n, renderbuffers)
+}
+
+func (rec *Recorder) BufferData(target uint32, size int, data unsafe.Pointer, usage uint32) {
+	rec.record("BufferData", target, size, bytesAt(data, size), usage)
+}
+
+func (rec *Recorder) BufferSubData(target uint32, offset int, size int, data unsafe.Pointer) {
+	rec.record("BufferSubData", target, offset, size, bytesAt(data, size))
+}
+
+func (rec *Recorder) CheckFramebufferStatus(target uint32) uint32 {
+	rec.record("CheckFramebufferStatus", target)
+	return gl.FRAMEBUFFER_COMPLETE
+}
+
+func (rec *Recorder) DrawBuffers(n int32, bufs *uint32) {
+	rec.record("DrawBuffers", n, append([]uint32(nil), uint32s(bufs, int(n))...))
+}
+
+func (rec *Recorder) TexParameterfv(target uint32, pname uint32, params *float32) {
+	n := 1
+	if pname == gl.TEXTURE_BORDER_COLOR {
+		n = 4
+	}
+	rec.record("TexParameterfv", target, pname, append([]float32(nil), float32s(params, n)...))
+}
+
+func (rec *Recorder) CreateShader(xtype uint32) uint32 {
+	rec.lastID++
+	rec.record("CreateShader", xtype, rec.lastID)
+	return rec.lastID
+}
+
+func (rec *Recorder) CreateProgram() uint32 {
+	rec.lastID++
+	rec.record("CreateProgram", rec.lastID)
+	return rec.lastID
+}
+
+func (rec *Recorder) GetShaderiv(shader uint32, pname uint32, params *int32) {
+	rec.record("GetShaderiv", shader, pname)
+	*params = 0
+	if pname == gl.COMPILE_STATUS {
+		*params = gl.TRUE
+	}
+}
+
+func (rec *Recorder) GetShaderInfoLog(shader uint32, bufSize int32, length *int32, infoLog *uint8) {
+	rec.record("GetShaderInfoLog", shader, bufSize)
+	writeName("", bufSize, length, infoLog)
+}
+
+func (rec *Recorder) GetProgramiv(program uint32, pname uint32, params *int32) {
+	rec.record("GetProgramiv", program, pname)
+	*params = 0
+	switch pname {
+	case gl.LINK_STATUS:
+		*params = gl.TRUE
+	case gl.ACTIVE_UNIFORMS:
+		*params = int32(len(rec.Uniforms))
+	case gl.ACTIVE_UNIFORM_MAX_LENGTH:
+		for _, u := range rec.Uniforms {
+			if n := int32(len(activeUniformName(u)) + 1); n > *params {
+				*params = n
+			}
+		}
+	}
+}
+
+func (rec *Recorder) GetProgramInfoLog(program uint32, bufSize int32, length *int32, infoLog *uint8) {
+	rec.record("GetProgramInfoLog", program, bufSize)
+	writeName("", bufSize, length, infoLog)
+}
+
+func (rec *Recorder) GetActiveUniform(program uint32, index uint32, bufSize int32, length *int32, size *int32, xtype *uint32, name *uint8) {
+	rec.record("GetActiveUniform", program, index, bufSize)
+	u := rec.Uniforms[index]
+	*size = uniformSize(u)
+	*xtype = u.Type
+	writeName(activeUniformName(u), bufSize, length, name)
+}
+
+// Programs have no active attributes, so this is never called by the renderer
+func (rec *Recorder) GetActiveAttrib(program uint32, index uint32, bufSize int32, length *int32, size *int32, xtype *uint32, name *uint8) {
+	rec.record("GetActiveAttrib", program, index, bufSize)
+	writeName("", bufSize, length, name)
+}
+
+func (rec *Recorder) GetAttribLocation(program uint32, name *uint8) int32 {
+	rec.record("GetAttribLocation", program, gl.GoStr(name))
+	return -1
+}
+
+func (rec *Recorder) GetUniformLocation(program uint32, name *uint8) int32 {
+	n := gl.GoStr(name)
+	rec.record("GetUniformLocation", program, n)
+	return rec.uniformLocation(n)
+}
+
+func (rec *Recorder) GetUniformBlockIndex(program uint32, uniformBlockName *uint8) uint32 {
+	rec.record("GetUniformBlockIndex", program, gl.GoStr(uniformBlockName))
+	return gl.INVALID_INDEX
+}
+
+func (rec *Recorder) GetActiveUniformBlockName(program uint32, uniformBlockIndex uint32, bufSize int32, length *int32, uniformBlockName *uint8) {
+	rec.record("GetActiveUniformBlockName", program, uniformBlockIndex, bufSize)
+	writeName("", bufSize, length, uniformBlockName)
+}
+
+func (rec *Recorder) GetActiveUniformBlockiv(program uint32, uniformBlockIndex uint32, pname uint32, params *int32) {
+	rec.record("GetActiveUniformBlockiv", program, uniformBlockIndex, pname)
+	*params = 0
+}
+
+func (rec *Recorder) Uniform1fv(location int32, count int32, value *float32) {
+	rec.uniform("Uniform1fv", location, count, append([]float32(nil), float32s(value, int(count))...))
+}
+
+func (rec *Recorder) Uniform1iv(location int32, count int32, value *int32) {
+	rec.uniform("Uniform1iv", location, count, append([]int32(nil), int32s(value, int(count))...))
+}
+
+func (rec *Recorder) Uniform1uiv(location int32, count int32, value *uint32) {
+	rec.uniform("Uniform1uiv", location, count, append([]uint32(nil), uint32s(value, int(count))...))
+}
+
+func (rec *Recorder) Uniform2fv(location int32, count int32, value *float32) {
+	rec.uniform("Uniform2fv", location, count, append([]float32(nil), float32s(value, 2*int(count))...))
+}
+
+func (rec *Recorder) Uniform2iv(location int32, count int32, value *int32) {
+	rec.uniform("Uniform2iv", location, count, append([]int32(nil), int32s(value, 2*int(count))...))
+}
+
+func (rec *Recorder) Uniform2uiv(location int32, count int32, value *uint32) {
+	rec.uniform("Uniform2uiv", location, count, append([]uint32(nil), uint32s(value, 2*int(count))...))
+}
+
+func (rec *Recorder) Uniform3fv(location int32, count int32, value *float32) {
+	rec.uniform("Uniform3fv", location, count, append([]float32(nil), float32s(value, 3*int(count))...))
+}
+
+func (rec *Recorder) Uniform3iv(location int32, count int32, value *int32) {
+	rec.uniform("Uniform3iv", location, count, append([]int32(nil), int32s(value, 3*int(count))...))
+}
+
+func (rec *Recorder) Uniform3uiv(location int32, count int32, value *uint32) {
+	rec.uniform("Uniform3uiv", location, count, append([]uint32(nil), uint32s(value, 3*int(count))...))
+}
+
+func (rec *Recorder) Uniform4fv(location int32, count int32, value *float32) {
+	rec.uniform("Uniform4fv", location, count, append([]float32(nil), float32s(value, 4*int(count))...))
+}
+
+func (rec *Recorder) Uniform4iv(location int32, count int32, value *int32) {
+	rec.uniform("Uniform4iv", location, count, append([]int32(nil), int32s(value, 4*int(count))...))
+}
+
+func (rec *Recorder) Uniform4uiv(location int32, count int32, value *uint32) {
+	rec.uniform("Uniform4uiv", location, count, append([]uint32(nil), uint32s(value, 4*int(count))...))
+}
+
+func (rec *Recorder) UniformMatrix2fv(location int32, count int32, transpose bool, value *float32) {
+	rec.uniform("UniformMatrix2fv", location, count, transpose, append([]float32(nil), float32s(value, 4*int(count))...))
+}
+
+func (rec *Recorder) UniformMatrix3fv(location int32, count int32, transpose bool, value *float32) {
+	rec.uniform("UniformMatrix3fv", location, count, transpose, append([]float32(nil), float32s(value, 9*int(count))...))
+}
+
+func (rec *Recorder) UniformMatrix4fv(location int32, count int32, transpose bool, value *float32) {
+	rec.uniform("UniformMatrix4fv", location, count, transpose, append([]float32(nil), float32s(value, 16*int(count))...))
+}
+
+func (rec *Recorder) Enable(cap uint32) {
+	rec.record("Enable", cap)
+}
+
+func (rec *Recorder) Disable(cap uint32) {
+	rec.record("Disable", cap)
+}
+
+func (rec *Recorder) DepthFunc(xfunc uint32) {
+	rec.record("DepthFunc", xfunc)
+}
+
+func (rec *Recorder) DepthMask(flag bool) {
+	rec.record("DepthMask", flag)
+}
+
+func (rec *Recorder) BlendFunc(sfactor uint32, dfactor uint32) {
+	rec.record("BlendFunc", sfactor, dfactor)
+}
+
+func (rec *Recorder) CullFace(mode uint32) {
+	rec.record("CullFace", mode)
+}
+
+func (rec *Recorder) FrontFace(mode uint32) {
+	rec.record("FrontFace", mode)
+}
+
+func (rec *Recorder) ClearColor(r float32, g float32, b float32, a float32) {
+	rec.record("ClearColor", r, g, b, a)
+}
+
+func (rec *Recorder) Clear(mask uint32) {
+	rec.record("Clear", mask)
+}
+
+func (rec *Recorder) Finish() {
+	rec.record("Finish")
+}
+
+func (rec *Recorder) PixelStorei(pname uint32, param int32) {
+	rec.record("PixelStorei", pname, param)
+}
+
+func (rec *Recorder) DeleteBuffers(n int32, buffers *uint32) {
+	rec.record("DeleteBuffers", n)
+}
+
+func (rec *Recorder) BindBuffer(target uint32, buffer uint32) {
+	rec.record("BindBuffer", target, buffer)
+}
+
+func (rec *Recorder) BindBufferBase(target uint32, index uint32, buffer uint32) {
+	rec.record("BindBufferBase", target, index, buffer)
+}
+
+func (rec *Recorder) DeleteVertexArrays(n int32, arrays *uint32) {
+	rec.record("DeleteVertexArrays", n)
+}
+
+func (rec *Recorder) BindVertexArray(array uint32) {
+	rec.record("BindVertexArray", array)
+}
+
+func (rec *Recorder) EnableVertexAttribArray(index uint32) {
+	rec.record("EnableVertexAttribArray", index)
+}
+
+func (rec *Recorder) VertexAttribPointer(index uint32, size int32, xtype uint32, normalized bool, stride int32, pointer unsafe.Pointer) {
+	rec.record("VertexAttribPointer", index, size, xtype, normalized, stride)
+}
+
+func (rec *Recorder) VertexAttribDivisor(index uint32, divisor uint32) {
+	rec.record("VertexAttribDivisor", index, divisor)
+}
+
+func (rec *Recorder) DrawArrays(mode uint32, first int32, count int32) {
+	rec.record("DrawArrays", mode, first, count)
+}
+
+func (rec *Recorder) DrawArraysInstanced(mode uint32, first int32, count int32, instancecount int32) {
+	rec.record("DrawArraysInstanced", mode, first, count, instancecount)
+}
+
+func (rec *Recorder) DrawElements(mode uint32, count int32, xtype uint32, indices unsafe.Pointer) {
+	rec.record("DrawElements", mode, count, xtype)
+}
+
+func (rec *Recorder) DrawElementsInstanced(mode uint32, count int32, xtype uint32, indices unsafe.Pointer, instancecount int32) {
+	rec.record("DrawElementsInstanced", mode, count, xtype, instancecount)
+}
+
+func (rec *Recorder) DeleteTextures(n int32, textures *uint32) {
+	rec.record("DeleteTextures", n)
+}
+
+func (rec *Recorder) ActiveTexture(texture uint32) {
+	rec.record("ActiveTexture", texture)
+}
+
+func (rec *Recorder) BindTexture(target uint32, texture uint32) {
+	rec.record("BindTexture", target, texture)
+}
+
+func (rec *Recorder) TexImage2D(target uint32, level int32, internalformat int32, width int32, height int32, border int32, format uint32, xtype uint32, pixels unsafe.Pointer) {
+	rec.record("TexImage2D", target, level, internalformat, width, height, border, format, xtype)
+}
+
+func (rec *Recorder) TexImage3D(target uint32, level int32, internalformat int32, width int32, height int32, depth int32, border int32, format uint32, xtype uint32, pixels unsafe.Pointer) {
+	rec.record("TexImage3D", target, level, internalformat, width, height, depth, border, format, xtype)
+}
+
+func (rec *Recorder) TexSubImage3D(target uint32, level int32, xoffset int32, yoffset int32, zoffset int32, width int32, height int32, depth int32, format uint32, xtype uint32, pixels unsafe.Pointer) {
+	rec.record("TexSubImage3D", target, level, xoffset, yoffset, zoffset, width, height, depth, format, xtype)
+}
+
+func (rec *Recorder) TexParameteri(target uint32, pname uint32, param int32) {
+	rec.record("TexParameteri", target, pname, param)
+}
+
+func (rec *Recorder) TexParameterf(target uint32, pname uint32, param float32) {
+	rec.record("TexParameterf", target, pname, param)
+}
+
+func (rec *Recorder) GenerateMipmap(target uint32) {
+	rec.record("GenerateMipmap", target)
+}
+
+func (rec *Recorder) DeleteFramebuffers(n int32, framebuffers *uint32) {
+	rec.record("DeleteFramebuffers", n)
+}
+
+func (rec *Recorder) BindFramebuffer(target uint32, framebuffer uint32) {
+	if target == gl.FRAMEBUFFER {
+		rec.framebuffer = framebuffer
+	}
+	rec.record("BindFramebuffer", target, framebuffer)
+}
+
+func (rec *Recorder) FramebufferTexture2D(target uint32, attachment uint32, textarget uint32, texture uint32, level int32) {
+	rec.record("FramebufferTexture2D", target, attachment, textarget, texture, level)
+}
+
+func (rec *Recorder) DeleteRenderbuffers(n int32, renderbuffers *uint32) {
+	rec.record("DeleteRenderbuffers", n)
+}
+
+func (rec *Recorder) BindRenderbuffer(target uint32, renderbuffer uint32) {
+	rec.record("BindRenderbuffer", target, renderbuffer)
+}
+
+func (rec *Recorder) RenderbufferStorage(target uint32, internalformat uint32, width int32, height int32) {
+	rec.record("RenderbufferStorage", target, internalformat, width, height)
+}
+
+func (rec *Recorder) FramebufferRenderbuffer(target uint32, attachment uint32, renderbuffertarget uint32, renderbuffer uint32) {
+	rec.record("FramebufferRenderbuffer", target, attachment, renderbuffertarget, renderbuffer)
+}
+
+func (rec *Recorder) DrawBuffer(buf uint32) {
+	rec.record("DrawBuffer", buf)
+}
+
+func (rec *Recorder) ReadBuffer(src uint32) {
+	rec.record("ReadBuffer", src)
+}
+
+func (rec *Recorder) ReadPixels(x int32, y int32, width int32, height int32, format uint32, xtype uint32, pixels unsafe.Pointer) {
+	rec.record("ReadPixels", x, y, width, height, format, xtype)
+}
+
+func (rec *Recorder) ShaderSource(shader uint32, count int32, xstring **uint8, length *int32) {
+	rec.record("ShaderSource", shader, count)
+}
+
+func (rec *Recorder) CompileShader(shader uint32) {
+	rec.record("CompileShader", shader)
+}
+
+func (rec *Recorder) DeleteShader(shader uint32) {
+	rec.record("DeleteShader", shader)
+}
+
+func (rec *Recorder) AttachShader(program uint32, shader uint32) {
+	rec.record("AttachShader", program, shader)
+}
+
+func (rec *Recorder) LinkProgram(program uint32) {
+	rec.record("LinkProgram", program)
+}
+
+func (rec *Recorder) UseProgram(program uint32) {
+	rec.record("UseProgram", program)
+}
+
+func (rec *Recorder) DeleteProgram(program uint32) {
+	rec.record("DeleteProgram", program)
+}
+
+func (rec *Recorder) UniformBlockBinding(program uint32, uniformBlockIndex uint32, uniformBlockBinding uint32) {
+	rec.record("UniformBlockBinding", program, uniformBlockIndex, uniformBlockBinding)
+}
+
+func (rec *Recorder) Uniform1f(location int32, v0 float32) {
+	rec.uniform("Uniform1f", location, v0)
+}
+
+func (rec *Recorder) Uniform2f(location int32, v0 float32, v1 float32) {
+	rec.uniform("Uniform2f", location, v0, v1)
+}
+
+func (rec *Recorder) Uniform3f(location int32, v0 float32, v1 float32, v2 float32) {
+	rec.uniform("Uniform3f", location, v0, v1, v2)
+}
+
+func (rec *Recorder) Uniform4f(location int32, v0 float32, v1 float32, v2 float32, v3 float32) {
+	rec.uniform("Uniform4f", location, v0, v1, v2, v3)
+}
+
+func (rec *Recorder) Uniform1i(location int32, v0 int32) {
+	rec.uniform("Uniform1i", location, v0)
+}
+
+func (rec *Recorder) Uniform2i(location int32, v0 int32, v1 int32) {
+	rec.uniform("Uniform2i", location, v0, v1)
+}
+
+func (rec *Recorder) Uniform3i(location int32, v0 int32, v1 int32, v2 int32) {
+	rec.uniform("Uniform3i", location, v0, v1, v2)
+}
+
+func (rec *Recorder) Uniform4i(location int32, v0 int32, v1 int32, v2 int32, v3 int32) {
+	rec.uniform("Uniform4i", location, v0, v1, v2, v3)
+}
+
+func (rec *Recorder) Uniform1ui(location int32, v0 uint32) {
+	rec.uniform("Uniform1ui", location, v0)
+}
+
+func (rec *Recorder) Uniform2ui(location int32, v0 uint32, v1 uint32) {
+	rec.uniform("Uniform2ui", location, v0, v1)
+}
+
+func (rec *Recorder) Uniform3ui(location int32, v0 uint32, v1 uint32, v2 uint32) {
+	rec.uniform("Uniform3ui", location, v0, v1, v2)
+}
+
+func (rec *Recorder) Uniform4ui(location int32, v0 uint32, v1 uint32, v2 uint32, v3 uint32) {
+	rec.uniform("Uniform4ui", location, v0, v1, v2, v3)
+}
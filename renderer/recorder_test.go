@@ -0,0 +1,71 @@
+package renderer
+
+import (
+	"testing"
+
+	"github.com/go-gl/gl/v4.3-core/gl"
+)
+
+func TestRecorderUniformLocations(t *testing.T) {
+	rec := NewRecorder(testUniforms...)
+	tests := []struct {
+		name     string
+		location int32
+	}{
+		{"model", 0},
+		{"lights", 1},
+		{"lights[0]", 1},
+		{"lights[1]", 2},
+		{"lights[3]", 4},
+		{"enabled", 5},
+		{"shadowMaps", 6},
+		{"shadowMaps[1]", 7},
+	}
+	for _, tt := range tests {
+		if loc := rec.uniformLocation(tt.name); loc != tt.location {
+			t.Errorf("%s is at location %d, want %d", tt.name, loc, tt.location)
+		}
+	}
+
+	// Arrays go by their name at their first location, like UniformSets expects
+	for loc, name := range []string{"model", "lights", "lights[1]", "lights[2]", "lights[3]", "enabled", "shadowMaps", "shadowMaps[1]"} {
+		if got := rec.uniformAt(int32(loc)); got != name {
+			t.Errorf("location %d holds %q, want %q", loc, got, name)
+		}
+	}
+
+	for _, name := range []string{"missing", "lights[4]", "lights[-1]", "lights[1]x", "lights[]", "lights[1", "lightsx", "enabled[1]", "shadowMaps[2]"} {
+		if loc := rec.uniformLocation(name); loc != -1 {
+			t.Errorf("%s is at location %d, want -1", name, loc)
+		}
+	}
+	for _, loc := range []int32{-1, 8, 100} {
+		if got := rec.uniformAt(loc); got != "" {
+			t.Errorf("location %d holds %q, want none", loc, got)
+		}
+	}
+}
+
+func TestRecorderBufferData(t *testing.T) {
+	rec := NewRecorder()
+	data := []float32{1, 2}
+	rec.BufferData(gl.ARRAY_BUFFER, 8, gl.Ptr(data), gl.STATIC_DRAW)
+	rec.BufferSubData(gl.ARRAY_BUFFER, 4, 4, gl.Ptr(data[1:]))
+	rec.BufferData(gl.ARRAY_BUFFER, 16, nil, gl.STATIC_DRAW)
+	data[0], data[1] = 0, 0 // The commands keep copies
+
+	cmds := rec.Commands()
+	want := []string{
+		"BufferData(34962, 8, [0 0 128 63 0 0 0 64], 35044)",
+		"BufferSubData(34962, 4, 4, [0 0 0 64])",
+		"BufferData(34962, 16, [], 35044)",
+	}
+	if len(cmds) != len(want) {
+		t.Fatalf("got %v", cmds)
+	}
+	for i := range want {
+		if cmds[i].String() != want[i] {
+			t.Errorf("got %v, want %s", cmds[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,299 @@
+package renderer
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/go-gl/gl/v4.3-core/gl"
+)
+
+// An active uniform of a program
+// Uniforms of a block have no location, they are set through the block's buffer
+type Uniform struct {
+	Name     string // Arrays are named without the [0] suffix
+	Type     uint32 // e.g. gl.FLOAT_VEC3
+	Size     int32  // Number of elements, 1 unless an array
+	Location int32  // -1 for uniforms of a block
+}
+
+// An active uniform block of a program
+type UniformBlock struct {
+	Name     string
+	Binding  uint32
+	DataSize int32 // In bytes
+}
+
+// An active vertex attribute of a program
+type Attribute struct {
+	Name     string
+	Type     uint32
+	Size     int32
+	Location int32
+}
+
+// GLSL names of the uniform and attribute types, for messages
+var glslTypes = map[uint32]string{
+	gl.FLOAT:             "float",
+	gl.FLOAT_VEC2:        "vec2",
+	gl.FLOAT_VEC3:        "vec3",
+	gl.FLOAT_VEC4:        "vec4",
+	gl.INT:               "int",
+	gl.INT_VEC2:          "ivec2",
+	gl.INT_VEC3:          "ivec3",
+	gl.INT_VEC4:          "ivec4",
+	gl.UNSIGNED_INT:      "uint",
+	gl.UNSIGNED_INT_VEC2: "uvec2",
+	gl.UNSIGNED_INT_VEC3: "uvec3",
+	gl.UNSIGNED_INT_VEC4: "uvec4",
+	gl.BOOL:              "bool",
+	gl.BOOL_VEC2:         "bvec2",
+	gl.BOOL_VEC3:         "bvec3",
+	gl.BOOL_VEC4:         "bvec4",
+	gl.FLOAT_MAT2:        "mat2",
+	gl.FLOAT_MAT3:        "mat3",
+	gl.FLOAT_MAT4:        "mat4",
+
+	gl.SAMPLER_2D:              "sampler2D",
+	gl.SAMPLER_3D:              "sampler3D",
+	gl.SAMPLER_CUBE:            "samplerCube",
+	gl.SAMPLER_2D_ARRAY:        "sampler2DArray",
+	gl.SAMPLER_2D_SHADOW:       "sampler2DShadow",
+	gl.INT_SAMPLER_2D:          "isampler2D",
+	gl.UNSIGNED_INT_SAMPLER_2D: "usampler2D",
+}
+
+func typeName(t uint32) string {
+	if name, ok := glslTypes[t]; ok {
+		return name
+	}
+	return fmt.Sprintf("0x%X", t)
+}
+
+func isSampler(t uint32) bool {
+	return strings.Contains(glslTypes[t], "sampler")
+}
+
+// Stands for any sampler type in Shader.location
+const anySampler uint32 = 0
+
+// Checks the reflected type of a uniform against the one a setter wants
+func checkType(name string, got, want uint32) error {
+	if got == want || want == anySampler && isSampler(got) {
+		return nil
+	}
+	wantName := typeName(want)
+	if want == anySampler {
+		wantName = "sampler"
+	}
+	return fmt.Errorf("uniform %q is a %s, not a %s", name, typeName(got), wantName)
+}
+
+// Reads the active uniforms, uniform blocks and attributes of the linked program
+func (s *Shader) reflect() {
+	s.uniforms = make(map[string]*Uniform)
+	s.blocks = make(map[string]*UniformBlock)
+	s.attributes = make(map[string]*Attribute)
+	s.elements = make(map[string][]int32)
+
+	var count, maxLen int32
+	backend.GetProgramiv(s.rendererID, gl.ACTIVE_UNIFORMS, &count)
+	backend.GetProgramiv(s.rendererID, gl.ACTIVE_UNIFORM_MAX_LENGTH, &maxLen)
+	for i := uint32(0); i < uint32(count); i++ {
+		var size int32
+		var typ uint32
+		name := activeName(maxLen, func(buf *uint8, length *int32) {
+			backend.GetActiveUniform(s.rendererID, i, maxLen+1, length, &size, &typ, buf)
+		})
+		location := backend.GetUniformLocation(s.rendererID, gl.Str(name+"\x00"))
+		name = strings.TrimSuffix(name, "[0]")
+		s.uniforms[name] = &Uniform{Name: name, Type: typ, Size: size, Location: location}
+	}
+
+	backend.GetProgramiv(s.rendererID, gl.ACTIVE_UNIFORM_BLOCKS, &count)
+	backend.GetProgramiv(s.rendererID, gl.ACTIVE_UNIFORM_BLOCK_MAX_NAME_LENGTH, &maxLen)
+	for i := uint32(0); i < uint32(count); i++ {
+		name := activeName(maxLen, func(buf *uint8, length *int32) {
+			backend.GetActiveUniformBlockName(s.rendererID, i, maxLen+1, length, buf)
+		})
+		var binding, dataSize int32
+		backend.GetActiveUniformBlockiv(s.rendererID, i, gl.UNIFORM_BLOCK_BINDING, &binding)
+		backend.GetActiveUniformBlockiv(s.rendererID, i, gl.UNIFORM_BLOCK_DATA_SIZE, &dataSize)
+		s.blocks[name] = &UniformBlock{Name: name, Binding: uint32(binding), DataSize: dataSize}
+	}
+
+	backend.GetProgramiv(s.rendererID, gl.ACTIVE_ATTRIBUTES, &count)
+	backend.GetProgramiv(s.rendererID, gl.ACTIVE_ATTRIBUTE_MAX_LENGTH, &maxLen)
+	for i := uint32(0); i < uint32(count); i++ {
+		var size int32
+		var typ uint32
+		name := activeName(maxLen, func(buf *uint8, length *int32) {
+			backend.GetActiveAttrib(s.rendererID, i, maxLen+1, length, &size, &typ, buf)
+		})
+		location := backend.GetAttribLocation(s.rendererID, gl.Str(name+"\x00"))
+		s.attributes[name] = &Attribute{Name: name, Type: typ, Size: size, Location: location}
+	}
+}
+
+// Calls a glGetActive* function with a buffer large enough for maxLen and returns the name
+func activeName(maxLen int32, get func(buf *uint8, length *int32)) string {
+	buf := make([]uint8, maxLen+1)
+	var length int32
+	get(&buf[0], &length)
+	return string(buf[:length])
+}
+
+// Returns all active uniforms, sorted by name
+func (s *Shader) Uniforms() []Uniform {
+	us := make([]Uniform, 0, len(s.uniforms))
+	for _, u := range s.uniforms {
+		us = append(us, *u)
+	}
+	sort.Slice(us, func(i, j int) bool { return us[i].Name < us[j].Name })
+	return us
+}
+
+// Returns all active uniform blocks, sorted by name
+func (s *Shader) UniformBlocks() []UniformBlock {
+	bs := make([]UniformBlock, 0, len(s.blocks))
+	for _, b := range s.blocks {
+		bs = append(bs, *b)
+	}
+	sort.Slice(bs, func(i, j int) bool { return bs[i].Name < bs[j].Name })
+	return bs
+}
+
+// Returns all active attributes, sorted by location
+func (s *Shader) Attributes() []Attribute {
+	as := make([]Attribute, 0, len(s.attributes))
+	for _, a := range s.attributes {
+		as = append(as, *a)
+	}
+	sort.Slice(as, func(i, j int) bool { return as[i].Location < as[j].Location })
+	return as
+}
+
+// Reports whether the program has an active uniform by that name
+// Uniforms the compiler found unused are not active
+func (s *Shader) HasUniform(name string) bool {
+	_, _, err := s.lookupUniform(name)
+	return err == nil
+}
+
+// Checks that the program has an active uniform of type typ, without touching GL
+// Meant to catch mismatches when a material is set up rather than when it is drawn
+func (s *Shader) CheckUniform(name string, typ uint32) error {
+	u, _, err := s.lookupUniform(name)
+	if err != nil {
+		return err
+	}
+	if u.Location == -1 {
+		return fmt.Errorf("uniform %q belongs to a uniform block", name)
+	}
+	return checkType(name, u.Type, typ)
+}
+
+// Checks that the program has an active sampler of any type by that name
+func (s *Shader) CheckSampler(name string) error {
+	return s.CheckUniform(name, anySampler)
+}
+
+// Splits an array element (e.g. "lights[2]") into the array's name and the index
+// Indices too large for an int32 come back as math.MaxInt32
+func splitElement(name string) (string, int32, bool) {
+	open := strings.LastIndexByte(name, '[')
+	if open <= 0 || open+2 >= len(name) || name[len(name)-1] != ']' {
+		return "", 0, false
+	}
+	var idx int32
+	for _, c := range name[open+1 : len(name)-1] {
+		if c < '0' || c > '9' {
+			return "", 0, false
+		}
+		if idx < math.MaxInt32/10 {
+			idx = idx*10 + int32(c-'0')
+		} else {
+			idx = math.MaxInt32
+		}
+	}
+	return name[:open], idx, true
+}
+
+// Finds an active uniform, array elements (e.g. "lights[2]") resolve to
+// their array along with their index
+// Only errors allocate
+func (s *Shader) lookupUniform(name string) (*Uniform, int32, error) {
+	if u, ok := s.uniforms[name]; ok {
+		return u, 0, nil
+	}
+	if array, idx, ok := splitElement(name); ok {
+		if u, ok := s.uniforms[array]; ok {
+			if idx >= u.Size {
+				return nil, 0, fmt.Errorf("uniform %q is out of the bounds of %s[%d]", name, array, u.Size)
+			}
+			return u, idx, nil
+		}
+	}
+	return nil, 0, fmt.Errorf("uniform %q is not active", name)
+}
+
+// Marks element locations elementLocation has not looked up yet
+const unresolvedLocation int32 = -2
+
+// Returns the location of an element of an array uniform
+// GL only promises consecutive locations for explicit ones, so every element is
+// looked up, the first time it is set
+func (s *Shader) elementLocation(u *Uniform, idx int32) (int32, error) {
+	locs := s.elements[u.Name]
+	if locs == nil {
+		locs = make([]int32, u.Size)
+		for i := range locs {
+			locs[i] = unresolvedLocation
+		}
+		s.elements[u.Name] = locs
+	}
+	if locs[idx] == unresolvedLocation {
+		locs[idx] = backend.GetUniformLocation(s.rendererID, gl.Str(fmt.Sprintf("%s[%d]\x00", u.Name, idx)))
+	}
+	if locs[idx] == -1 {
+		return -1, fmt.Errorf("uniform %s[%d] has no location", u.Name, idx)
+	}
+	return locs[idx], nil
+}
+
+// Returns the location to set count elements of a uniform of type want from
+// Uniforms that are not active are reported once, type mismatches are errors
+// CheckUniform runs the same checks up front, e.g. when a material is created
+// Once an element's location is cached nothing allocates unless there is an error
+func (s *Shader) location(name string, count int, want uint32) (int32, error) {
+	name = strings.TrimRight(name, "\x00")
+	u, idx, err := s.lookupUniform(name)
+	if err != nil {
+		if !s.warned[name] {
+			s.warned[name] = true
+			log.Printf("shader %s: %v", s.name(), err)
+		}
+		return -1, err
+	}
+	if u.Location == -1 {
+		return -1, fmt.Errorf("uniform %q belongs to a uniform block", name)
+	}
+	if err := checkType(name, u.Type, want); err != nil {
+		return -1, err
+	}
+	if idx+int32(count) > u.Size {
+		return -1, fmt.Errorf("setting %d elements from %q overflows %s[%d]", count, name, u.Name, u.Size)
+	}
+	if idx == 0 {
+		return u.Location, nil
+	}
+	return s.elementLocation(u, idx)
+}
+
+// Names the program after its shader files, for messages
+func (s *Shader) name() string {
+	return fmt.Sprintf("%s+%s", filepath.Base(s.vertexPath), filepath.Base(s.fragmentPath))
+}
@@ -2,8 +2,11 @@ package renderer
 
 import (
 	"fmt"
+	"image"
 	"os"
 	"path"
+	"strings"
+	"time"
 
 	"github.com/go-gl/gl/v4.3-core/gl"
 	"github.com/go-gl/mathgl/mgl32"
@@ -11,24 +14,70 @@ import (
 
 const (
 	shadersPath = "res/shaders"
+
+	// Suffix of the program drawing many instances of what <name> draws once
+	instancedSuffix = "_instanced"
 )
 
+// Every default program and the shaders it is built from.
+// For a shader name <s> we expect to find <s>_vertex.glsl or <s>_fragment.glsl under the shadersPath.
+// Instanced variants are the same shaders compiled with INSTANCED defined
+var defaultPrograms = []struct {
+	name, vertex, fragment string
+	defines                map[string]string
+}{
+	{"basic", "basic", "basic", nil},
+	{"phong", "phong", "phong", nil},
+	{"lamp", "lamp", "lamp", nil},
+	{"skybox", "skybox", "skybox", nil},
+	{"basic" + instancedSuffix, "basic", "basic", instancedDefines},
+	{"phong" + instancedSuffix, "phong", "phong", instancedDefines},
+	{"lamp" + instancedSuffix, "lamp", "lamp", instancedDefines},
+}
+
+var instancedDefines = map[string]string{"INSTANCED": ""}
+
 // This should be given temporarily because of vim-go
 var rootPath = os.Getenv("PROJ_PATH") // e.g. /home/lgian/go/src/github.com/linosgian/goph3d
 
 type Renderer struct {
-	vaos         []*VertexArray
-	textures     []*Texture
-	programNames map[string]int
-	Programs     []*Shader
+	vaos           []*VertexArray
+	meshes         map[string]*Mesh // Named meshes, see LoadMesh
+	textures       []*Texture
+	textureKeys    map[string]int        // Cache of loaded textures, by path or content
+	textureRefs    map[int]*textureEntry // Reference counts, by texture ID
+	programNames   map[string]int
+	instanced      map[int]int // Program ID to the ID of its instanced variant
+	Programs       []*Shader
+	lastShaderPoll time.Time // Last time ReloadPrograms looked for changes
+	pointLights    *StorageBuffer
+	frame          *UniformBuffer // Frame block, see UploadFrame
+	lights         *UniformBuffer // Lights block, see UploadLights
+	view           mgl32.Mat4     // View matrix of the current frame
+	sky            *skybox        // See SetSkybox
+	framebuffers   []*framebufferEntry
+	meshChecks     map[[2]int]error  // Results of checkMesh, by program and vertex array ID
+	unitTargets    map[uint32]uint32 // Target of the texture BindTexture last bound to every unit
+
+	queue          []queuedItem     // Items submitted since the last Flush
+	queueMaterials map[Material]int // Order materials were submitted in
+	cache          *stateCache      // Set while flushing
 }
 
 func NewRenderer() (*Renderer, error) {
 	r := &Renderer{
 		vaos:         make([]*VertexArray, 0),
+		meshes:       make(map[string]*Mesh),
 		textures:     make([]*Texture, 0),
+		textureKeys:  make(map[string]int),
+		textureRefs:  make(map[int]*textureEntry),
 		programNames: make(map[string]int, 0),
+		instanced:    make(map[int]int),
 		Programs:     make([]*Shader, 0),
+		meshChecks:   make(map[[2]int]error),
+		unitTargets:  make(map[uint32]uint32),
+
+		queueMaterials: make(map[Material]int),
 	}
 
 	// Load all default shaders
@@ -39,91 +88,271 @@ func NewRenderer() (*Renderer, error) {
 
 }
 
-func (r *Renderer) DrawRaw(vaoID, programID, texID int, view, proj, model mgl32.Mat4) error {
+// Draws a vertex array with a program and the given textures, each on a unit of its own
+func (r *Renderer) DrawRaw(vaoID, programID int, textures []TextureBinding, model mgl32.Mat4) error {
+	if err := r.checkMesh(programID, vaoID); err != nil {
+		return err
+	}
 	s := r.Programs[programID]
 	va := r.vaos[vaoID]
 
 	s.Bind()
 	va.Bind()
 
-	// TODO: Improve this by holding an ID for the texture instead of loading
-	// it to the first slot all the time
-	// Load the right texture for the object
-	r.textures[texID].Bind(0)
-	s.SetUniform1i("aTexture\x00", 0)
+	if err := r.BindTextures(s, textures); err != nil {
+		return err
+	}
 
-	// Camera
-	s.SetMat4("view\x00", &view[0])
+	// Camera and perspective come from the Frame block, see UploadFrame
+	// Model matrix
+	s.SetMat4("model", model)
 
-	// Perspective matrix
-	s.SetMat4("projection\x00", &proj[0])
+	va.Draw()
+	return nil
+}
 
-	// Model matrix
-	s.SetMat4("model\x00", &model[0])
+// Draws a vertex array with the program, textures and state of a material
+// normal is the normal matrix of model, see NormalMatrix
+func (r *Renderer) Draw(vaoID int, m Material, model mgl32.Mat4, normal mgl32.Mat3) error {
+	if err := r.checkMesh(m.ProgramID(), vaoID); err != nil {
+		return err
+	}
+	s := r.Programs[m.ProgramID()]
+	va := r.vaos[vaoID]
+
+	s.Bind()
+	va.Bind()
+
+	m.RenderState().apply()
+	m.Apply(r, s)
+
+	s.SetMat4("model", model)
+	if s.HasUniform("normalMatrix") {
+		s.SetMat3("normalMatrix", normal)
+	}
 
-	gl.DrawArrays(gl.TRIANGLES, 0, va.DataSize/va.Vcount)
+	va.Draw()
 	return nil
 }
 
+// Draws many instances of a vertex array in a single draw call, one per model matrix
+// The material's program must have an instanced variant (see InstancedProgram),
+// which reads the model and normal matrices from the instance attributes
+// Normal matrices are computed when there isn't one per model matrix
+func (r *Renderer) DrawInstanced(vaoID int, m Material, models []mgl32.Mat4, normals []mgl32.Mat3) error {
+	if len(models) == 0 {
+		return nil
+	}
+	if len(normals) != len(models) {
+		normals = NormalMatrices(models)
+	}
+	programID, ok := r.InstancedProgram(m.ProgramID())
+	if !ok {
+		return fmt.Errorf("program %d has no instanced variant", m.ProgramID())
+	}
+	if err := r.checkMesh(programID, vaoID); err != nil {
+		return err
+	}
+	s := r.Programs[programID]
+	va := r.vaos[vaoID]
+
+	s.Bind()
+	va.SetInstances(models, normals)
+
+	m.RenderState().apply()
+	m.Apply(r, s)
+
+	va.DrawInstanced(int32(len(models)))
+	return nil
+}
+
+// Checks that a vertex array carries every attribute a program reads
+// The result is kept until the program is reloaded
+func (r *Renderer) checkMesh(programID, vaoID int) error {
+	key := [2]int{programID, vaoID}
+	if err, ok := r.meshChecks[key]; ok {
+		return err
+	}
+	var err error
+	if layout := r.vaos[vaoID].Layout(); layout == nil {
+		err = fmt.Errorf("vertex array %d has no data", vaoID)
+	} else if err = layout.Supports(r.Programs[programID]); err != nil {
+		err = fmt.Errorf("cannot draw vertex array %d with program %d: %v", vaoID, programID, err)
+	}
+	r.meshChecks[key] = err
+	return err
+}
+
+// Finds the instanced variant of a program
+// Returns false if there is none
+func (r *Renderer) InstancedProgram(programID int) (int, bool) {
+	pID, ok := r.instanced[programID]
+	return pID, ok
+}
+
+// Binds a texture to a texture unit
+// NoTexture clears the unit, whatever target its texture had, so no stale texture is sampled
+func (r *Renderer) BindTexture(texID int, slot uint32) {
+	if r.cache != nil {
+		if bound, ok := r.cache.textures[slot]; ok && bound == texID {
+			return
+		}
+		r.cache.textures[slot] = texID
+	}
+	if texID == NoTexture {
+		target, ok := r.unitTargets[slot]
+		if !ok {
+			target = gl.TEXTURE_2D
+		}
+		backend.ActiveTexture(gl.TEXTURE0 + slot)
+		backend.BindTexture(target, 0)
+		delete(r.unitTargets, slot)
+		return
+	}
+	t := r.textures[texID]
+	t.Bind(slot)
+	r.unitTargets[slot] = t.target
+}
+
 // Loads a texture for a specific program (shader)
+// A file that is already loaded with the same options is not read again, its ID is returned instead
+// Every call takes a reference, give it back with ReleaseTexture
 // Returns an internal object ID
-func (r *Renderer) LoadTexture(texturePath string, programID int) (int, error) {
+func (r *Renderer) LoadTexture(texturePath string, programID int, opts TextureOptions) (int, error) {
+	key := pathKey(texturePath) + "|" + opts.key()
+	if objID, ok := r.cachedTexture(key); ok {
+		return objID, nil
+	}
+
 	r.Programs[programID].Bind()
-	t, err := NewTexture(texturePath)
+	t, err := NewTexture(texturePath, opts)
 	if err != nil {
 		return 0, err
 	}
-	objID := len(r.textures)
-	r.textures = append(r.textures, t)
+	objID := r.addTexture(key, t)
+	r.Programs[programID].Unbind()
+	return objID, nil
+}
+
+// Loads an already decoded image as a texture for a specific program (shader)
+// Images are cached by content, so identical images share a texture
+// Every call takes a reference, give it back with ReleaseTexture
+// Returns an internal object ID
+func (r *Renderer) LoadTextureImage(img image.Image, programID int, opts TextureOptions) (int, error) {
+	if err := opts.validate(); err != nil {
+		return 0, err
+	}
+	im, err := prepareImage(img)
+	if err != nil {
+		return 0, err
+	}
+	key := imageKey(im) + "|" + opts.key()
+	if objID, ok := r.cachedTexture(key); ok {
+		return objID, nil
+	}
+
+	r.Programs[programID].Bind()
+	objID := r.addTexture(key, newTexture(im, opts))
 	r.Programs[programID].Unbind()
 	return objID, nil
 }
 
 // Loads a program with both the fragment and vertex shaders
+// The shaders are preprocessed, see preprocess, with the defines injected after #version
+// A program named after another one with the "_instanced" suffix becomes its instanced variant
 // Returns an internal object ID
-func (r *Renderer) loadProgram(progName, vsPath, fsPath string) error {
-	s, err := NewShader(vsPath, fsPath)
+func (r *Renderer) LoadProgram(progName, vsPath, fsPath string, defines map[string]string) (int, error) {
+	if _, ok := r.programNames[progName]; ok {
+		return 0, fmt.Errorf("a program named %q is already loaded", progName)
+	}
+	s, err := NewShader(vsPath, fsPath, defines)
 	if err != nil {
-		return err
+		return 0, err
 	}
-	r.programNames[progName] = len(r.Programs)
+	bindUniformBlocks(s)
+	objID := len(r.Programs)
+	r.programNames[progName] = objID
 	r.Programs = append(r.Programs, s)
-	return nil
+
+	// Instanced variants are loaded after the program they belong to
+	if base, ok := r.programNames[strings.TrimSuffix(progName, instancedSuffix)]; ok && strings.HasSuffix(progName, instancedSuffix) {
+		r.instanced[base] = objID
+	}
+	return objID, nil
 }
 
-// Loads a vertex buffer
+// Loads a vertex buffer with the default position/texture/normal layout
 // Returns an internal object ID
 func (r *Renderer) LoadData(data []float32) (int, error) {
-	vb := NewVertexBuffer(data, len(data)*sizes[FLOAT])
-	va := NewVertexArray()
+	return r.LoadMeshData(data, nil, DefaultLayout())
+}
+
+// Loads a vertex buffer alongside an index buffer
+// Shared vertices are stored once and referenced by the indices,
+// the data is drawn with glDrawElements
+// Returns an internal object ID
+func (r *Renderer) LoadIndexedData(vertices []float32, indices []uint32) (int, error) {
+	if len(indices) == 0 {
+		return 0, fmt.Errorf("no indices given for indexed data")
+	}
+	return r.LoadMeshData(vertices, indices, DefaultLayout())
+}
+
+// Loads a vertex buffer described by a caller-supplied layout
+// indices may be nil for non-indexed data
+// Returns an internal object ID
+func (r *Renderer) LoadMeshData(vertices []float32, indices []uint32, vbl *VertexBufferLayout) (int, error) {
+	if err := vbl.Validate(len(vertices)); err != nil {
+		return 0, err
+	}
+	vertexCount := uint32(len(vertices) / int(vbl.Vcount))
+	for _, idx := range indices {
+		if idx >= vertexCount {
+			return 0, fmt.Errorf("index %d out of range for %d vertices", idx, vertexCount)
+		}
+	}
 
-	vbl := new(VertexBufferLayout)
-	vbl.PushFloat(3) // position: a fvec3
-	vbl.PushFloat(2) // texture: a fvec2
-	vbl.PushFloat(3) // Normals: a fvec2
+	vb := NewVertexBuffer(vertices, len(vertices)*sizes[FLOAT])
+	va := NewVertexArray()
 
 	va.Vcount = vbl.Vcount // I DONT LIKE THIS SHIT. Reconsider in the future
-	va.DataSize = int32(len(data))
+	va.DataSize = int32(len(vertices))
 	va.AddBuffer(vb, vbl)
 
+	var ib *IndexBuffer
+	if len(indices) > 0 {
+		ib = NewIndexBuffer(indices)
+		va.AddIndexBuffer(ib)
+	}
+
 	// State should remain clean after each load
+	// Unbind the VAO first so it keeps its index buffer
 	va.Unbind()
 	vb.Unbind()
+	if ib != nil {
+		ib.Unbind()
+	}
 
 	objID := len(r.vaos)
 	r.vaos = append(r.vaos, va)
 	return objID, nil
 }
 
-// Loads all default shader programs
-// For any new program name added to programNames
-// we expect to find <name>_vertex.glsl and <name>_fragment.glsl under the shadersPath
+// Uploads the packed point light array read by the lit shaders
+// The buffer is created on first use and bound to PointLightsBinding
+func (r *Renderer) UploadPointLights(data []float32) {
+	if r.pointLights == nil {
+		r.pointLights = NewStorageBuffer(PointLightsBinding)
+	}
+	r.pointLights.Upload(data)
+}
+
+// Loads all default shader programs, see defaultPrograms
 func (r *Renderer) LoadDefaultPrograms() error {
-	programNames := []string{"basic", "phong", "lamp"}
-	for _, pName := range programNames {
-		vsPath := path.Join(rootPath, shadersPath, fmt.Sprintf("%s_vertex.glsl", pName))
-		fsPath := path.Join(rootPath, shadersPath, fmt.Sprintf("%s_fragment.glsl", pName))
-		if err := r.loadProgram(pName, vsPath, fsPath); err != nil {
+	for _, p := range defaultPrograms {
+		vsPath := path.Join(rootPath, shadersPath, fmt.Sprintf("%s_vertex.glsl", p.vertex))
+		fsPath := path.Join(rootPath, shadersPath, fmt.Sprintf("%s_fragment.glsl", p.fragment))
+		if _, err := r.LoadProgram(p.name, vsPath, fsPath, p.defines); err != nil {
 			return err
 		}
 	}
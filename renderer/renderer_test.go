@@ -0,0 +1,109 @@
+package renderer
+
+import (
+	"image"
+	"image/color"
+	"reflect"
+	"testing"
+
+	"github.com/go-gl/gl/v4.3-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// Creates a renderer with the default programs on a Recorder
+// Shaders are read from the repository root, the tests run in renderer/
+func testRenderer(t *testing.T, uniforms ...Uniform) (*Renderer, *Recorder) {
+	t.Helper()
+	rec := useRecorder(t, uniforms...)
+	if rootPath == "" {
+		rootPath = ".."
+		t.Cleanup(func() { rootPath = "" })
+	}
+	r, err := NewRenderer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec.Reset()
+	return r, rec
+}
+
+// Two triangles of a quad, in the default layout
+var quad = []float32{
+	-1, -1, 0, 0, 0, 0, 0, 1,
+	1, -1, 0, 1, 0, 0, 0, 1,
+	1, 1, 0, 1, 1, 0, 0, 1,
+	1, 1, 0, 1, 1, 0, 0, 1,
+	-1, 1, 0, 0, 1, 0, 0, 1,
+	-1, -1, 0, 0, 0, 0, 0, 1,
+}
+
+func TestDrawRaw(t *testing.T) {
+	r, rec := testRenderer(t, Uniform{Name: "model", Type: gl.FLOAT_MAT4})
+	basic, err := r.GetProgram("basic")
+	if err != nil {
+		t.Fatal(err)
+	}
+	vao, err := r.LoadData(quad)
+	if err != nil {
+		t.Fatal(err)
+	}
+	model := mgl32.Translate3D(1, 2, 3)
+
+	rec.Reset()
+	if err := r.DrawRaw(vao, basic, nil, model); err != nil {
+		t.Fatal(err)
+	}
+	draws := rec.Calls("DrawArrays")
+	if len(draws) != 1 || len(rec.Calls("DrawElements")) != 0 {
+		t.Fatalf("got %v, want a single DrawArrays", rec.Commands())
+	}
+	if want := []interface{}{uint32(gl.TRIANGLES), int32(0), int32(6)}; !reflect.DeepEqual(draws[0].Args, want) {
+		t.Errorf("got %v, want DrawArrays%v", draws[0], want)
+	}
+	sets := rec.UniformSets("model")
+	if len(sets) != 1 || !reflect.DeepEqual(sets[0].Args[2], model[:]) {
+		t.Errorf("got %v setting the model matrix", sets)
+	}
+
+	// The same quad from 4 vertices and 6 indices
+	indexed, err := r.LoadIndexedData(append(append([]float32(nil), quad[:24]...), quad[32:40]...), []uint32{0, 1, 2, 2, 3, 0})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec.Reset()
+	if err := r.DrawRaw(indexed, basic, nil, model); err != nil {
+		t.Fatal(err)
+	}
+	draws = rec.Calls("DrawElements")
+	if len(draws) != 1 || len(rec.Calls("DrawArrays")) != 0 {
+		t.Fatalf("got %v, want a single DrawElements", rec.Commands())
+	}
+	if want := []interface{}{uint32(gl.TRIANGLES), int32(6), uint32(gl.UNSIGNED_INT)}; !reflect.DeepEqual(draws[0].Args, want) {
+		t.Errorf("got %v, want DrawElements%v", draws[0], want)
+	}
+}
+
+func TestBindNoTexture(t *testing.T) {
+	r, rec := testRenderer(t)
+	basic, err := r.GetProgram("basic")
+	if err != nil {
+		t.Fatal(err)
+	}
+	img := image.NewNRGBA(image.Rect(0, 0, 1, 1))
+	img.SetNRGBA(0, 0, color.NRGBA{255, 255, 255, 255})
+	tex, err := r.LoadTextureImage(img, basic, DefaultTextureOptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r.BindTexture(tex, 3)
+	rec.Reset()
+	r.BindTexture(NoTexture, 3)
+	want := []Command{
+		{Name: "ActiveTexture", Args: []interface{}{uint32(gl.TEXTURE0 + 3)}},
+		{Name: "BindTexture", Args: []interface{}{uint32(gl.TEXTURE_2D), uint32(0)}},
+	}
+	if got := rec.Commands(); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
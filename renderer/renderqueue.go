@@ -0,0 +1,196 @@
+package renderer
+
+import (
+	"log"
+	"sort"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// DrawItem is a single draw submitted to the render queue
+// Normal matrices are computed from the model matrices when left empty
+type DrawItem struct {
+	VaoID    int
+	Material Material     // Must be comparable, e.g. a pointer, and not nil
+	Model    mgl32.Mat4   // Used when Models is nil
+	Normal   mgl32.Mat3   // Normal matrix of Model, see NormalMatrix
+	Models   []mgl32.Mat4 // Instance model matrices, drawn with the program's instanced variant
+	Normals  []mgl32.Mat3 // Instance normal matrices
+}
+
+// An item along with everything it is sorted by
+type queuedItem struct {
+	DrawItem
+	program     int
+	material    int // Order the material was first submitted in
+	texture     int
+	depth       float32 // Squared distance from the camera
+	transparent bool
+}
+
+// The GL state set by the last draw of a Flush
+// Binds matching it are skipped
+type stateCache struct {
+	program  int
+	vao      int
+	state    RenderState
+	material Material
+	textures map[uint32]int // Slot to texture ID
+}
+
+func newStateCache() *stateCache {
+	return &stateCache{
+		program:  -1,
+		vao:      -1,
+		textures: make(map[uint32]int),
+	}
+}
+
+// Queues an item, it is drawn by the next Flush
+// Instanced items whose program has no instanced variant are queued once per instance
+// Items whose vertex array lacks an attribute the program reads are dropped
+func (r *Renderer) Submit(item DrawItem) {
+	programID := item.Material.ProgramID()
+	if item.Models != nil {
+		pID, ok := r.InstancedProgram(programID)
+		if !ok {
+			for i, m := range item.Models {
+				it := DrawItem{VaoID: item.VaoID, Material: item.Material, Model: m}
+				if i < len(item.Normals) {
+					it.Normal = item.Normals[i]
+				}
+				r.Submit(it)
+			}
+			return
+		}
+		if len(item.Models) == 0 {
+			return
+		}
+		if len(item.Normals) != len(item.Models) {
+			item.Normals = NormalMatrices(item.Models)
+		}
+		programID = pID
+	} else if item.Normal == (mgl32.Mat3{}) {
+		item.Normal = NormalMatrix(item.Model)
+	}
+
+	// A mismatch is only reported the first time, the item is dropped every frame
+	_, checked := r.meshChecks[[2]int{programID, item.VaoID}]
+	if err := r.checkMesh(programID, item.VaoID); err != nil {
+		if !checked {
+			log.Printf("skipping draw: %v", err)
+		}
+		return
+	}
+
+	m, ok := r.queueMaterials[item.Material]
+	if !ok {
+		m = len(r.queueMaterials)
+		r.queueMaterials[item.Material] = m
+	}
+
+	r.queue = append(r.queue, queuedItem{
+		DrawItem:    item,
+		program:     programID,
+		material:    m,
+		texture:     item.Material.TextureID(),
+		transparent: item.Material.RenderState().Blend,
+	})
+}
+
+// Draws everything submitted since the last Flush and empties the queue
+// Opaque items are sorted by program, material, texture and then front-to-back,
+// transparent ones are drawn last, back-to-front
+// The skybox, if any, is drawn in between the two
+// Depth is measured from the camera given to the last UploadFrame
+func (r *Renderer) Flush() {
+	camPos := r.view.Inv().Col(3).Vec3()
+	for i := range r.queue {
+		it := &r.queue[i]
+		model := it.Model
+		if it.Models != nil {
+			model = it.Models[0]
+		}
+		d := model.Col(3).Vec3().Sub(camPos)
+		it.depth = d.Dot(d)
+	}
+	sort.SliceStable(r.queue, func(i, j int) bool {
+		a, b := &r.queue[i], &r.queue[j]
+		if a.transparent != b.transparent {
+			return !a.transparent
+		}
+		if a.transparent {
+			return a.depth > b.depth
+		}
+		if a.program != b.program {
+			return a.program < b.program
+		}
+		if a.material != b.material {
+			return a.material < b.material
+		}
+		if a.texture != b.texture {
+			return a.texture < b.texture
+		}
+		return a.depth < b.depth
+	})
+
+	r.cache = newStateCache()
+	skyDrawn := false
+	for i := range r.queue {
+		if r.queue[i].transparent && !skyDrawn {
+			r.drawSkybox()
+			skyDrawn = true
+		}
+		r.drawQueued(&r.queue[i])
+	}
+	r.cache = nil
+	if !skyDrawn {
+		r.drawSkybox()
+	}
+
+	r.queue = r.queue[:0]
+	for m := range r.queueMaterials {
+		delete(r.queueMaterials, m)
+	}
+}
+
+func (r *Renderer) drawQueued(it *queuedItem) {
+	c := r.cache
+	s := r.Programs[it.program]
+	va := r.vaos[it.VaoID]
+
+	programChanged := c.program != it.program
+	if programChanged {
+		s.Bind()
+		c.program = it.program
+	}
+
+	if it.Models != nil {
+		// Uploading the instances binds the vertex array
+		va.SetInstances(it.Models, it.Normals)
+		c.vao = it.VaoID
+	} else if c.vao != it.VaoID {
+		va.Bind()
+		c.vao = it.VaoID
+	}
+
+	if rs := it.Material.RenderState(); c.material == nil || rs != c.state {
+		rs.apply()
+		c.state = rs
+	}
+	// Uniforms belong to the program, so a material is only applied again after a program switch
+	if programChanged || c.material != it.Material {
+		it.Material.Apply(r, s)
+		c.material = it.Material
+	}
+
+	if it.Models != nil {
+		va.DrawInstanced(int32(len(it.Models)))
+		return
+	}
+	s.SetMat4("model", it.Model)
+	if s.HasUniform("normalMatrix") {
+		s.SetMat3("normalMatrix", it.Normal)
+	}
+	va.Draw()
+}
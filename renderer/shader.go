@@ -2,171 +2,172 @@ package renderer
 
 import (
 	"fmt"
+	"os"
 	"strings"
-
-	"io/ioutil"
+	"time"
 
 	"github.com/go-gl/gl/v4.3-core/gl"
-	"github.com/go-gl/mathgl/mgl32"
 )
 
 type Shader struct {
 	rendererID           uint32           // A private ID for the object (e.g. OpenGL object ID)
 	uniformLocationCache map[string]int32 // Cache for uniform locations
+
+	vertexPath, fragmentPath string
+	defines                  map[string]string
+	modTimes                 map[string]time.Time // Modification time of every source and included file, as compiled
+
+	// Filled by reflect after linking
+	uniforms   map[string]*Uniform
+	blocks     map[string]*UniformBlock
+	attributes map[string]*Attribute
+	elements   map[string][]int32 // Locations of array elements by array name, see elementLocation
+	warned     map[string]bool    // Uniforms already reported missing
 }
 
-func NewShader(vertexPath, fragmentPath string) (*Shader, error) {
+// Compiles a program out of a vertex and a fragment shader
+// Both are run through the preprocessor with the given defines, see preprocess
+func NewShader(vertexPath, fragmentPath string, defines map[string]string) (*Shader, error) {
 	s := Shader{
-		rendererID:           gl.CreateProgram(),
+		rendererID:           backend.CreateProgram(),
 		uniformLocationCache: make(map[string]int32),
+		vertexPath:           vertexPath,
+		fragmentPath:         fragmentPath,
+		defines:              defines,
+		modTimes:             make(map[string]time.Time),
+		warned:               make(map[string]bool),
 	}
 
 	// Compile shaders
-	vsf, err := ioutil.ReadFile(vertexPath)
+	vsSource, err := preprocess(vertexPath, defines)
+	s.track(vsSource)
 	if err != nil {
 		return &s, fmt.Errorf("Could not read shader file for vertex shader: %q", err)
 	}
-	// TODO: Concat strings more effeciently and make a wrapper for the null character
-	vsSource := fmt.Sprintf("%s%s", vsf, "\x00") // Make it a C-Style null-terminated string
 	vs, err := s.compileShader(gl.VERTEX_SHADER, vsSource)
 	if err != nil {
 		return &s, err
 	}
 
-	fsf, err := ioutil.ReadFile(fragmentPath)
+	fsSource, err := preprocess(fragmentPath, defines)
+	s.track(fsSource)
 	if err != nil {
 		return &s, fmt.Errorf("Could not read shader file for fragment shader: %q", err)
 	}
-	fsSource := fmt.Sprintf("%s%s", fsf, "\x00")
-
 	fs, err := s.compileShader(gl.FRAGMENT_SHADER, fsSource)
 	if err != nil {
 		return &s, err
 	}
 
-	gl.AttachShader(s.rendererID, vs)
-	gl.AttachShader(s.rendererID, fs)
-	gl.LinkProgram(s.rendererID)
+	backend.AttachShader(s.rendererID, vs)
+	backend.AttachShader(s.rendererID, fs)
+	backend.LinkProgram(s.rendererID)
 
 	// TODO: Abstract error handling
 	var success int32
-	gl.GetProgramiv(s.rendererID, gl.LINK_STATUS, &success)
+	backend.GetProgramiv(s.rendererID, gl.LINK_STATUS, &success)
 	if success == gl.FALSE {
 		var logLength int32
-		gl.GetShaderiv(s.rendererID, gl.INFO_LOG_LENGTH, &logLength)
+		backend.GetShaderiv(s.rendererID, gl.INFO_LOG_LENGTH, &logLength)
 
 		log := strings.Repeat("\x00", int(logLength+1))
-		gl.GetProgramInfoLog(s.rendererID, logLength, nil, gl.Str(log))
+		backend.GetProgramInfoLog(s.rendererID, logLength, nil, gl.Str(log))
 		return &s, fmt.Errorf("failed to link: %q", log)
 	}
 
 	// Delete shaders as they are linked already
-	gl.DeleteShader(vs)
-	gl.DeleteShader(fs)
+	backend.DeleteShader(vs)
+	backend.DeleteShader(fs)
+
+	s.reflect()
 	return &s, nil
 }
 
-func (s *Shader) compileShader(shaderType uint32, source string) (uint32, error) {
-	id := gl.CreateShader(shaderType)
+func (s *Shader) compileShader(shaderType uint32, source *shaderSource) (uint32, error) {
+	id := backend.CreateShader(shaderType)
 
-	src, free := gl.Strs(source)
-	gl.ShaderSource(id, 1, src, nil)
+	// TODO: Concat strings more effeciently and make a wrapper for the null character
+	src, free := gl.Strs(source.text + "\x00") // Make it a C-Style null-terminated string
+	backend.ShaderSource(id, 1, src, nil)
 	free()
 
-	gl.CompileShader(id)
+	backend.CompileShader(id)
 
 	var status int32
-	gl.GetShaderiv(id, gl.COMPILE_STATUS, &status)
+	backend.GetShaderiv(id, gl.COMPILE_STATUS, &status)
 
 	// If an error occured, grab the info
 	if status == gl.FALSE {
 		var logLength int32
-		gl.GetShaderiv(id, gl.INFO_LOG_LENGTH, &logLength)
+		backend.GetShaderiv(id, gl.INFO_LOG_LENGTH, &logLength)
 
 		log := strings.Repeat("\x00", int(logLength+1))
-		gl.GetShaderInfoLog(id, logLength, nil, gl.Str(log))
+		backend.GetShaderInfoLog(id, logLength, nil, gl.Str(log))
 
-		gl.DeleteShader(id)
-		return 0, fmt.Errorf("failed to compile\n%v: %v", source, log)
+		backend.DeleteShader(id)
+		return 0, fmt.Errorf("failed to compile %s:\n%v", source.files[0], source.remapLog(strings.TrimRight(log, "\x00")))
 	}
 	return id, nil
 }
 
-func (s *Shader) Bind() {
-	gl.UseProgram(s.rendererID)
-}
-
-func (s *Shader) Unbind() {
-	gl.UseProgram(0)
-}
-
-func (s *Shader) SetUniform1i(name string, val int32) error {
-	nullTermString := fmt.Sprintf("%s\x00", name)
-	location, err := s.GetUniformLocation(nullTermString)
-	if err != nil {
-		return err
+// Remembers the files a source was read from, to notice when they change
+func (s *Shader) track(source *shaderSource) {
+	for p, t := range source.modTimes {
+		s.modTimes[p] = t
 	}
-	gl.Uniform1i(location, val)
-	return nil
 }
 
-func (s *Shader) SetMat4(name string, v *float32) error {
-	nullTermString := fmt.Sprintf("%s\x00", name)
-	location, err := s.GetUniformLocation(nullTermString)
-	if err != nil {
-		return err
+// Reports whether any source file changed since the program was compiled
+func (s *Shader) Changed() bool {
+	for p, t := range s.modTimes {
+		fi, err := os.Stat(p)
+		// A missing file is most likely an editor in the middle of saving it
+		if err == nil && !fi.ModTime().Equal(t) {
+			return true
+		}
 	}
-	gl.UniformMatrix4fv(location, 1, false, v)
-	return nil
+	return false
 }
 
-func (s *Shader) SetFloat(name string, val float32) error {
-	nullTermString := fmt.Sprintf("%s\x00", name)
-	location, err := s.GetUniformLocation(nullTermString)
+// Compiles the program again from its source files
+// The program is only replaced if everything compiles and links,
+// otherwise the last good one stays in place
+func (s *Shader) Reload() error {
+	ns, err := NewShader(s.vertexPath, s.fragmentPath, s.defines)
 	if err != nil {
+		backend.DeleteProgram(ns.rendererID)
+		// Don't try again until the files change once more
+		for p := range s.modTimes {
+			if fi, err := os.Stat(p); err == nil {
+				s.modTimes[p] = fi.ModTime()
+			}
+		}
+		for p, t := range ns.modTimes {
+			s.modTimes[p] = t
+		}
 		return err
 	}
-	gl.Uniform1f(location, val)
-	return nil
-}
-func (s *Shader) SetVec3f(name string, x, y, z float32) error {
-	nullTermString := fmt.Sprintf("%s\x00", name)
-	location, err := s.GetUniformLocation(nullTermString)
-	if err != nil {
-		return err
-	}
-	gl.Uniform3f(location, x, y, z)
+	backend.DeleteProgram(s.rendererID)
+	*s = *ns
 	return nil
 }
 
-func (s *Shader) SetVec3(name string, vec3 mgl32.Vec3) error {
-	nullTermString := fmt.Sprintf("%s\x00", name)
-	location, err := s.GetUniformLocation(nullTermString)
-	if err != nil {
-		return err
-	}
-	gl.Uniform3f(location, vec3.X(), vec3.Y(), vec3.Z())
-	return nil
+func (s *Shader) Bind() {
+	backend.UseProgram(s.rendererID)
 }
 
-func (s *Shader) SetUniform4f(name string, v0, v1, v2, v3 float32) error {
-	nullTermString := fmt.Sprintf("%s\x00", name)
-	location, err := s.GetUniformLocation(nullTermString)
-	if err != nil {
-		return err
-	}
-	gl.Uniform4f(location, v0, v1, v2, v3)
-	return nil
+func (s *Shader) Unbind() {
+	backend.UseProgram(0)
 }
 
 func (s *Shader) GetUniformLocation(name string) (int32, error) {
-	nullTermString := fmt.Sprintf("%s\x00", name)
 	// Check if it is cached
-	if val, ok := s.uniformLocationCache[name]; ok {
-		return val, nil
+	location, ok := s.uniformLocationCache[name]
+	if !ok {
+		nullTermString := fmt.Sprintf("%s\x00", name)
+		location = backend.GetUniformLocation(s.rendererID, gl.Str(nullTermString))
+		s.uniformLocationCache[name] = location
 	}
-	location := gl.GetUniformLocation(s.rendererID, gl.Str(nullTermString))
-	s.uniformLocationCache[name] = location
 	if location == -1 {
 		return 0, fmt.Errorf("Uniform variable location not found: %v", name)
 	}
@@ -0,0 +1,122 @@
+package renderer
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/go-gl/gl/v4.3-core/gl"
+)
+
+// A unit cube, seen from the inside
+var skyboxVertices = []float32{
+	-1, 1, -1, -1, -1, -1, 1, -1, -1, 1, -1, -1, 1, 1, -1, -1, 1, -1,
+	-1, -1, 1, -1, -1, -1, -1, 1, -1, -1, 1, -1, -1, 1, 1, -1, -1, 1,
+	1, -1, -1, 1, -1, 1, 1, 1, 1, 1, 1, 1, 1, 1, -1, 1, -1, -1,
+	-1, -1, 1, -1, 1, 1, 1, 1, 1, 1, 1, 1, 1, -1, 1, -1, -1, 1,
+	-1, 1, -1, 1, 1, -1, 1, 1, 1, 1, 1, 1, -1, 1, 1, -1, 1, -1,
+	-1, -1, -1, -1, -1, 1, 1, -1, -1, 1, -1, -1, -1, -1, 1, 1, -1, 1,
+}
+
+// The cubemap drawn behind the scene and what it is drawn with
+type skybox struct {
+	texID     int
+	vaoID     int
+	programID int
+}
+
+// Loads a cubemap from six images, see NewCubemap
+// Cached and reference counted like LoadTexture
+func (r *Renderer) LoadCubemap(faces [6]string, opts TextureOptions) (int, error) {
+	keys := make([]string, len(faces))
+	for i, f := range faces {
+		keys[i] = pathKey(f)
+	}
+	key := "cube:" + strings.Join(keys, ",") + "|" + opts.key()
+	if objID, ok := r.cachedTexture(key); ok {
+		return objID, nil
+	}
+	t, err := NewCubemap(faces, opts)
+	if err != nil {
+		return 0, err
+	}
+	return r.addTexture(key, t), nil
+}
+
+// Loads a cubemap out of an equirectangular panorama, see NewCubemapFromEquirect
+// Cached and reference counted like LoadTexture
+func (r *Renderer) LoadEquirectCubemap(path string, size int, opts TextureOptions) (int, error) {
+	key := fmt.Sprintf("equirect:%d:%s|%s", size, pathKey(path), opts.key())
+	if objID, ok := r.cachedTexture(key); ok {
+		return objID, nil
+	}
+	t, err := NewCubemapFromEquirect(path, size, opts)
+	if err != nil {
+		return 0, err
+	}
+	return r.addTexture(key, t), nil
+}
+
+// Draws a cubemap behind the scene on every Flush, NoTexture removes it
+// The renderer holds a reference to the cubemap while it is in use
+func (r *Renderer) SetSkybox(texID int) error {
+	if texID != NoTexture {
+		if texID < 0 || texID >= len(r.textures) || r.textures[texID] == nil {
+			return fmt.Errorf("no such texture: %d", texID)
+		}
+		if !r.textures[texID].Cubemap() {
+			return fmt.Errorf("texture %d is not a cubemap", texID)
+		}
+	}
+
+	if r.sky == nil {
+		programID, err := r.GetProgram("skybox")
+		if err != nil {
+			return err
+		}
+		vbl := &VertexBufferLayout{}
+		vbl.Push(Position, 3)
+		vaoID, err := r.LoadMeshData(skyboxVertices, nil, vbl)
+		if err != nil {
+			return err
+		}
+		r.sky = &skybox{texID: NoTexture, vaoID: vaoID, programID: programID}
+	}
+
+	// Taken after the setup above, so that a failed setup leaks no reference
+	if texID != NoTexture {
+		if err := r.AcquireTexture(texID); err != nil {
+			return err
+		}
+	}
+	if r.sky.texID != NoTexture {
+		r.ReleaseTexture(r.sky.texID)
+	}
+	r.sky.texID = texID
+	return nil
+}
+
+// Draws the skybox, if any
+// Meant to go after opaque geometry, so that only the uncovered pixels are shaded
+func (r *Renderer) drawSkybox() {
+	if r.sky == nil || r.sky.texID == NoTexture {
+		return
+	}
+	s := r.Programs[r.sky.programID]
+	s.Bind()
+	r.vaos[r.sky.vaoID].Bind()
+
+	RenderState{DepthTest: true}.apply()
+	// The sky is drawn at the far plane, where the depth buffer was cleared to
+	backend.DepthFunc(gl.LEQUAL)
+
+	r.BindTexture(r.sky.texID, 0)
+	s.SetSampler("skybox", 0)
+	r.vaos[r.sky.vaoID].Draw()
+
+	backend.DepthFunc(gl.LESS)
+
+	// Whatever is drawn next has to bind its own state again
+	if r.cache != nil {
+		r.cache = newStateCache()
+	}
+}
@@ -0,0 +1,49 @@
+package renderer
+
+import (
+	"github.com/go-gl/gl/v4.3-core/gl"
+)
+
+// Binding points of the storage buffers shared by all programs
+const (
+	PointLightsBinding uint32 = 0
+)
+
+// A shader storage buffer attached to a fixed binding point
+// Its storage grows with the uploaded data, so the shader side
+// can declare an unsized array
+type StorageBuffer struct {
+	rendererID uint32 // A private ID for the object (e.g. OpenGL object ID)
+	binding    uint32 // Binding point the shaders read it from
+	size       int    // Allocated size in bytes
+}
+
+func NewStorageBuffer(binding uint32) *StorageBuffer {
+	sb := StorageBuffer{binding: binding}
+	backend.GenBuffers(1, &sb.rendererID)
+	return &sb
+}
+
+// Replaces the contents of the buffer
+// Storage is only reallocated when the data does not fit anymore
+func (sb *StorageBuffer) Upload(data []float32) {
+	size := len(data) * sizes[FLOAT]
+	backend.BindBuffer(gl.SHADER_STORAGE_BUFFER, sb.rendererID)
+	if size > sb.size {
+		backend.BufferData(gl.SHADER_STORAGE_BUFFER, size, gl.Ptr(data), gl.DYNAMIC_DRAW)
+		sb.size = size
+	} else if size > 0 {
+		backend.BufferSubData(gl.SHADER_STORAGE_BUFFER, 0, size, gl.Ptr(data))
+	}
+	backend.BindBuffer(gl.SHADER_STORAGE_BUFFER, 0)
+	sb.Bind()
+}
+
+// Attaches the buffer to its binding point
+func (sb *StorageBuffer) Bind() {
+	backend.BindBufferBase(gl.SHADER_STORAGE_BUFFER, sb.binding, sb.rendererID)
+}
+
+func (sb *StorageBuffer) Delete() {
+	backend.DeleteBuffers(1, &sb.rendererID)
+}
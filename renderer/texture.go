@@ -15,54 +15,90 @@ import (
 
 type Texture struct {
 	rendererID uint32
+	target     uint32 // gl.TEXTURE_2D, gl.TEXTURE_CUBE_MAP or gl.TEXTURE_2D_ARRAY
 	filepath   string
 	data       []uint8 // Make this public maybe??
 	Width      int32
 	Height     int32
+	Layers     int32 // Number of layers of a texture array, 0 otherwise
 }
 
-// TODO: Receive all parameters needed, e.g. TEXTURE_WRAP_S
-func NewTexture(filepath string) (*Texture, error) {
+// Creates a texture from an image file, sampled as described by opts
+func NewTexture(filepath string, opts TextureOptions) (*Texture, error) {
+	if err := opts.validate(); err != nil {
+		return nil, err
+	}
 	im, err := ReadImageFile(filepath)
 	if err != nil {
 		return nil, err
 	}
+	t := newTexture(im, opts)
+	t.filepath = filepath
+	return t, nil
+}
+
+// Creates a texture from an already decoded image, e.g. one embedded in a model file
+func NewTextureFromImage(img image.Image, opts TextureOptions) (*Texture, error) {
+	if err := opts.validate(); err != nil {
+		return nil, err
+	}
+	im, err := prepareImage(img)
+	if err != nil {
+		return nil, err
+	}
+	return newTexture(im, opts), nil
+}
 
+// The options must be valid already
+func newTexture(im *image.NRGBA, opts TextureOptions) *Texture {
 	t := Texture{
-		data:     im.Pix,
-		Width:    int32(im.Rect.Size().X),
-		Height:   int32(im.Rect.Size().Y),
-		filepath: filepath,
+		target: gl.TEXTURE_2D,
+		data:   im.Pix,
+		Width:  int32(im.Rect.Size().X),
+		Height: int32(im.Rect.Size().Y),
 	}
 
-	gl.GenTextures(1, &t.rendererID)
-	gl.BindTexture(gl.TEXTURE_2D, t.rendererID)
+	backend.GenTextures(1, &t.rendererID)
+	backend.BindTexture(gl.TEXTURE_2D, t.rendererID)
 
-	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.LINEAR_MIPMAP_LINEAR)
-	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.LINEAR)
-	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_S, gl.REPEAT)
-	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_T, gl.REPEAT)
+	opts.apply(gl.TEXTURE_2D)
 
-	gl.TexImage2D(gl.TEXTURE_2D, 0, gl.RGBA8, t.Width, t.Height, 0, gl.RGBA, gl.UNSIGNED_BYTE, gl.Ptr(t.data))
-	gl.GenerateMipmap(gl.TEXTURE_2D)
-	gl.BindTexture(gl.TEXTURE_2D, 0)
-	return &t, nil
+	backend.TexImage2D(gl.TEXTURE_2D, 0, opts.internalFormat(), t.Width, t.Height, 0, gl.RGBA, gl.UNSIGNED_BYTE, gl.Ptr(t.data))
+	if opts.Mipmaps {
+		backend.GenerateMipmap(gl.TEXTURE_2D)
+	}
+	backend.BindTexture(gl.TEXTURE_2D, 0)
+	return &t
 }
 
 func (t *Texture) Delete() {
-	gl.DeleteTextures(1, &t.rendererID)
+	backend.DeleteTextures(1, &t.rendererID)
 }
 
 func (t *Texture) Bind(slot uint32) {
-	gl.ActiveTexture(gl.TEXTURE0 + slot)
-	gl.BindTexture(gl.TEXTURE_2D, t.rendererID)
+	backend.ActiveTexture(gl.TEXTURE0 + slot)
+	backend.BindTexture(t.target, t.rendererID)
 }
 
 func (t *Texture) Unbind() {
-	gl.BindTexture(gl.TEXTURE_2D, 0)
+	backend.BindTexture(t.target, 0)
+}
+
+// Reports whether the texture is a cubemap
+func (t *Texture) Cubemap() bool {
+	return t.target == gl.TEXTURE_CUBE_MAP
 }
 
 func ReadImageFile(filepath string) (*image.NRGBA, error) {
+	im, err := readImage(filepath)
+	if err != nil {
+		return nil, err
+	}
+	return prepareImage(im)
+}
+
+// Decodes an image file as is
+func readImage(filepath string) (image.Image, error) {
 	r, err := os.Open(filepath)
 	if err != nil {
 		return nil, fmt.Errorf("could not open image file: %v\n", err)
@@ -73,13 +109,27 @@ func ReadImageFile(filepath string) (*image.NRGBA, error) {
 	if err != nil {
 		return nil, fmt.Errorf("could not decode image file: %v\n", err)
 	}
-	rgba := image.NewRGBA(im.Bounds())
-	if rgba.Stride != rgba.Rect.Size().X*4 {
-		return nil, fmt.Errorf("unsupported stride")
+	return im, nil
+}
+
+// Converts an image to RGBA, laid out the way OpenGL reads it
+func prepareImage(im image.Image) (*image.NRGBA, error) {
+	rgba, err := toRGBA(im)
+	if err != nil {
+		return nil, err
 	}
-	draw.Draw(rgba, rgba.Bounds(), im, image.Point{0, 0}, draw.Src)
 	// NOTE: Instead of rotating at loading, the image itself should be rotated
 	// already when on disk.
 	// This is needed because OpenGL reads images from the bottom left corner
 	return imaging.Rotate180(rgba), nil
 }
+
+// Converts an image to tightly packed RGBA, keeping its orientation
+func toRGBA(im image.Image) (*image.RGBA, error) {
+	rgba := image.NewRGBA(im.Bounds())
+	if rgba.Stride != rgba.Rect.Size().X*4 {
+		return nil, fmt.Errorf("unsupported stride")
+	}
+	draw.Draw(rgba, rgba.Bounds(), im, im.Bounds().Min, draw.Src)
+	return rgba, nil
+}
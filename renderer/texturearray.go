@@ -0,0 +1,124 @@
+package renderer
+
+import (
+	"fmt"
+	"image"
+	"strings"
+
+	"github.com/go-gl/gl/v4.3-core/gl"
+)
+
+// Creates a 2D texture array with one layer per image, e.g. terrain materials
+// All images must have the same size, shaders pick a layer through a sampler2DArray
+func NewTextureArray(paths []string, opts TextureOptions) (*Texture, error) {
+	if err := opts.validate(); err != nil {
+		return nil, err
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("texture array without layers")
+	}
+	layers := make([]*image.NRGBA, len(paths))
+	for i, path := range paths {
+		im, err := ReadImageFile(path)
+		if err != nil {
+			return nil, err
+		}
+		if i > 0 && im.Rect.Size() != layers[0].Rect.Size() {
+			return nil, fmt.Errorf("layer %s is %v, expected %v like the first layer", path, im.Rect.Size(), layers[0].Rect.Size())
+		}
+		layers[i] = im
+	}
+	return newTextureArray(layers, opts), nil
+}
+
+// Creates a 2D texture array out of an atlas of equally sized tiles
+// Tiles become layers left to right, top to bottom
+// Unlike sampling an atlas directly, filtering and mipmaps never bleed between tiles
+func NewTextureArrayFromAtlas(path string, tileWidth, tileHeight int, opts TextureOptions) (*Texture, error) {
+	if err := opts.validate(); err != nil {
+		return nil, err
+	}
+	im, err := readImage(path)
+	if err != nil {
+		return nil, err
+	}
+	b := im.Bounds()
+	if tileWidth <= 0 || tileHeight <= 0 || b.Dx()%tileWidth != 0 || b.Dy()%tileHeight != 0 {
+		return nil, fmt.Errorf("atlas %s of %dx%d can't be split into %dx%d tiles", path, b.Dx(), b.Dy(), tileWidth, tileHeight)
+	}
+
+	var layers []*image.NRGBA
+	for y := b.Min.Y; y < b.Max.Y; y += tileHeight {
+		for x := b.Min.X; x < b.Max.X; x += tileWidth {
+			tile := image.NewRGBA(image.Rect(0, 0, tileWidth, tileHeight))
+			for ty := 0; ty < tileHeight; ty++ {
+				for tx := 0; tx < tileWidth; tx++ {
+					tile.Set(tx, ty, im.At(x+tx, y+ty))
+				}
+			}
+			layer, err := prepareImage(tile)
+			if err != nil {
+				return nil, err
+			}
+			layers = append(layers, layer)
+		}
+	}
+	t := newTextureArray(layers, opts)
+	t.filepath = path
+	return t, nil
+}
+
+func newTextureArray(layers []*image.NRGBA, opts TextureOptions) *Texture {
+	t := Texture{
+		target: gl.TEXTURE_2D_ARRAY,
+		Width:  int32(layers[0].Rect.Dx()),
+		Height: int32(layers[0].Rect.Dy()),
+		Layers: int32(len(layers)),
+	}
+
+	backend.GenTextures(1, &t.rendererID)
+	backend.BindTexture(gl.TEXTURE_2D_ARRAY, t.rendererID)
+	opts.apply(gl.TEXTURE_2D_ARRAY)
+
+	backend.TexImage3D(gl.TEXTURE_2D_ARRAY, 0, opts.internalFormat(), t.Width, t.Height, t.Layers, 0, gl.RGBA, gl.UNSIGNED_BYTE, nil)
+	for i, l := range layers {
+		backend.TexSubImage3D(gl.TEXTURE_2D_ARRAY, 0, 0, 0, int32(i), t.Width, t.Height, 1, gl.RGBA, gl.UNSIGNED_BYTE, gl.Ptr(l.Pix))
+	}
+	if opts.Mipmaps {
+		backend.GenerateMipmap(gl.TEXTURE_2D_ARRAY)
+	}
+	backend.BindTexture(gl.TEXTURE_2D_ARRAY, 0)
+	return &t
+}
+
+// Loads a texture array, see NewTextureArray
+// Cached and reference counted like LoadTexture
+func (r *Renderer) LoadTextureArray(paths []string, opts TextureOptions) (int, error) {
+	keys := make([]string, len(paths))
+	for i, p := range paths {
+		keys[i] = pathKey(p)
+	}
+	key := "array:" + strings.Join(keys, ",") + "|" + opts.key()
+	if objID, ok := r.cachedTexture(key); ok {
+		return objID, nil
+	}
+	t, err := NewTextureArray(paths, opts)
+	if err != nil {
+		return 0, err
+	}
+	return r.addTexture(key, t), nil
+}
+
+// Loads a texture array out of an atlas, see NewTextureArrayFromAtlas
+// Cached and reference counted like LoadTexture
+func (r *Renderer) LoadTextureAtlas(path string, tileWidth, tileHeight int, opts TextureOptions) (int, error) {
+	key := fmt.Sprintf("atlas:%dx%d:%s|%s", tileWidth, tileHeight, pathKey(path), opts.key())
+	if objID, ok := r.cachedTexture(key); ok {
+		return objID, nil
+	}
+	t, err := NewTextureArrayFromAtlas(path, tileWidth, tileHeight, opts)
+	if err != nil {
+		return 0, err
+	}
+	return r.addTexture(key, t), nil
+}
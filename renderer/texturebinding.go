@@ -0,0 +1,45 @@
+package renderer
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Texture units a program can sample from at once in every stage, the minimum GL guarantees
+const maxTextureUnits = 16
+
+// A texture bound to a sampler uniform of a program
+type TextureBinding struct {
+	Sampler string
+	TexID   int // NoTexture leaves the unit empty
+}
+
+// Binds every texture to a unit of its own, in order from unit 0,
+// and points its sampler uniform at it
+// Samplers the program does not have (e.g. compiled out) are skipped and take no unit
+// A binding that fails does not stop the others, all errors are returned together
+// The program must be bound already
+func (r *Renderer) BindTextures(s *Shader, bindings []TextureBinding) error {
+	var errs []string
+	unit := uint32(0)
+	for _, b := range bindings {
+		if !s.HasUniform(b.Sampler) {
+			continue
+		}
+		if err := s.CheckSampler(b.Sampler); err != nil {
+			errs = append(errs, err.Error())
+			continue
+		}
+		if unit == maxTextureUnits {
+			errs = append(errs, fmt.Sprintf("more than %d textures bound", maxTextureUnits))
+			break
+		}
+		r.BindTexture(b.TexID, unit)
+		s.SetSampler(b.Sampler, unit)
+		unit++
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("could not bind textures: %s", strings.Join(errs, "; "))
+	}
+	return nil
+}
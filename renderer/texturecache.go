@@ -0,0 +1,82 @@
+package renderer
+
+import (
+	"crypto/sha1"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"path/filepath"
+)
+
+// A cached texture: what it was loaded from and how many users it has
+type textureEntry struct {
+	key  string
+	refs int
+}
+
+// Cache key of a texture file
+// Paths are made absolute so different spellings of the same file match
+func pathKey(texturePath string) string {
+	if abs, err := filepath.Abs(texturePath); err == nil {
+		texturePath = abs
+	}
+	return "file:" + filepath.Clean(texturePath)
+}
+
+// Cache key of a decoded image, based on its pixels
+func imageKey(im *image.NRGBA) string {
+	h := sha1.New()
+	var dims [8]byte
+	binary.LittleEndian.PutUint32(dims[:4], uint32(im.Rect.Dx()))
+	binary.LittleEndian.PutUint32(dims[4:], uint32(im.Rect.Dy()))
+	h.Write(dims[:])
+	h.Write(im.Pix)
+	return fmt.Sprintf("image:%x", h.Sum(nil))
+}
+
+// Returns the texture cached under key, taking a reference to it
+func (r *Renderer) cachedTexture(key string) (int, bool) {
+	texID, ok := r.textureKeys[key]
+	if ok {
+		r.textureRefs[texID].refs++
+	}
+	return texID, ok
+}
+
+// Registers a freshly loaded texture with a single reference
+// Returns its internal object ID
+func (r *Renderer) addTexture(key string, t *Texture) int {
+	objID := len(r.textures)
+	r.textures = append(r.textures, t)
+	r.textureKeys[key] = objID
+	r.textureRefs[objID] = &textureEntry{key: key, refs: 1}
+	return objID
+}
+
+// Takes another reference to a texture, e.g. when a second material uses it
+func (r *Renderer) AcquireTexture(texID int) error {
+	e, ok := r.textureRefs[texID]
+	if !ok {
+		return fmt.Errorf("no such texture: %d", texID)
+	}
+	e.refs++
+	return nil
+}
+
+// Drops a reference to a texture
+// The GL texture is deleted along with the last reference and the ID becomes invalid
+func (r *Renderer) ReleaseTexture(texID int) error {
+	e, ok := r.textureRefs[texID]
+	if !ok {
+		return fmt.Errorf("no such texture: %d", texID)
+	}
+	e.refs--
+	if e.refs > 0 {
+		return nil
+	}
+	r.textures[texID].Delete()
+	r.textures[texID] = nil
+	delete(r.textureKeys, e.key)
+	delete(r.textureRefs, texID)
+	return nil
+}
@@ -0,0 +1,124 @@
+package renderer
+
+import (
+	"fmt"
+
+	"github.com/go-gl/gl/v4.3-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// Anisotropic filtering is core only since 4.6, these come from EXT_texture_filter_anisotropic
+const (
+	textureMaxAnisotropy    = 0x84FE
+	maxTextureMaxAnisotropy = 0x84FF
+)
+
+// TextureOptions describes how a texture is sampled and stored
+type TextureOptions struct {
+	WrapS, WrapT         int32      // e.g. gl.REPEAT, gl.CLAMP_TO_EDGE, gl.CLAMP_TO_BORDER
+	BorderColor          mgl32.Vec4 // Used with gl.CLAMP_TO_BORDER
+	MinFilter, MagFilter int32      // e.g. gl.LINEAR_MIPMAP_LINEAR, gl.NEAREST
+	Anisotropy           float32    // Maximum anisotropy, 1 or less disables it. Clamped to what the driver supports
+	Mipmaps              bool       // Generate mipmaps, needed by the *_MIPMAP_* min filters
+	SRGB                 bool       // Store the texture as sRGB, e.g. for color maps rendered with gamma correction
+}
+
+// Returns the options textures were always loaded with:
+// repeating, trilinear filtering with mipmaps, stored as linear
+func DefaultTextureOptions() TextureOptions {
+	return TextureOptions{
+		WrapS:     gl.REPEAT,
+		WrapT:     gl.REPEAT,
+		MinFilter: gl.LINEAR_MIPMAP_LINEAR,
+		MagFilter: gl.LINEAR,
+		Mipmaps:   true,
+	}
+}
+
+// Returns options for pixel art: nearest filtering, no mipmaps, clamped
+func PixelArtTextureOptions() TextureOptions {
+	return TextureOptions{
+		WrapS:     gl.CLAMP_TO_EDGE,
+		WrapT:     gl.CLAMP_TO_EDGE,
+		MinFilter: gl.NEAREST,
+		MagFilter: gl.NEAREST,
+	}
+}
+
+// Returns options for UI elements: linear filtering, no mipmaps, clamped
+func UITextureOptions() TextureOptions {
+	return TextureOptions{
+		WrapS:     gl.CLAMP_TO_EDGE,
+		WrapT:     gl.CLAMP_TO_EDGE,
+		MinFilter: gl.LINEAR,
+		MagFilter: gl.LINEAR,
+	}
+}
+
+func isMipmapFilter(filter int32) bool {
+	switch filter {
+	case gl.NEAREST_MIPMAP_NEAREST, gl.LINEAR_MIPMAP_NEAREST, gl.NEAREST_MIPMAP_LINEAR, gl.LINEAR_MIPMAP_LINEAR:
+		return true
+	}
+	return false
+}
+
+func isWrapMode(wrap int32) bool {
+	switch wrap {
+	case gl.REPEAT, gl.MIRRORED_REPEAT, gl.CLAMP_TO_EDGE, gl.CLAMP_TO_BORDER:
+		return true
+	}
+	return false
+}
+
+// Catches values GL would reject without a word (GL_INVALID_ENUM)
+// and combinations it would accept but sample as black
+func (o TextureOptions) validate() error {
+	if !isWrapMode(o.WrapS) {
+		return fmt.Errorf("invalid wrap mode 0x%X for S", o.WrapS)
+	}
+	if !isWrapMode(o.WrapT) {
+		return fmt.Errorf("invalid wrap mode 0x%X for T", o.WrapT)
+	}
+	if o.MinFilter != gl.NEAREST && o.MinFilter != gl.LINEAR && !isMipmapFilter(o.MinFilter) {
+		return fmt.Errorf("invalid min filter 0x%X", o.MinFilter)
+	}
+	if isMipmapFilter(o.MinFilter) && !o.Mipmaps {
+		return fmt.Errorf("min filter 0x%X needs mipmaps", o.MinFilter)
+	}
+	if o.MagFilter != gl.NEAREST && o.MagFilter != gl.LINEAR {
+		return fmt.Errorf("invalid mag filter 0x%X", o.MagFilter)
+	}
+	return nil
+}
+
+// The same image loaded with different options is a different texture
+func (o TextureOptions) key() string {
+	return fmt.Sprintf("%x/%x/%v/%x/%x/%v/%v/%v", o.WrapS, o.WrapT, o.BorderColor, o.MinFilter, o.MagFilter, o.Anisotropy, o.Mipmaps, o.SRGB)
+}
+
+// Returns the internal format matching the options
+func (o TextureOptions) internalFormat() int32 {
+	if o.SRGB {
+		return gl.SRGB8_ALPHA8
+	}
+	return gl.RGBA8
+}
+
+// Sets the sampling parameters of the texture bound to target
+func (o TextureOptions) apply(target uint32) {
+	backend.TexParameteri(target, gl.TEXTURE_MIN_FILTER, o.MinFilter)
+	backend.TexParameteri(target, gl.TEXTURE_MAG_FILTER, o.MagFilter)
+	backend.TexParameteri(target, gl.TEXTURE_WRAP_S, o.WrapS)
+	backend.TexParameteri(target, gl.TEXTURE_WRAP_T, o.WrapT)
+	if o.WrapS == gl.CLAMP_TO_BORDER || o.WrapT == gl.CLAMP_TO_BORDER {
+		backend.TexParameterfv(target, gl.TEXTURE_BORDER_COLOR, &o.BorderColor[0])
+	}
+	if o.Anisotropy > 1 {
+		var max float32
+		backend.GetFloatv(maxTextureMaxAnisotropy, &max)
+		if max > 0 {
+			backend.TexParameterf(target, textureMaxAnisotropy, mgl32.Clamp(o.Anisotropy, 1, max))
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package renderer
+
+import (
+	"image"
+	"testing"
+
+	"github.com/go-gl/gl/v4.3-core/gl"
+)
+
+func TestTextureOptionsValidate(t *testing.T) {
+	for name, opts := range map[string]TextureOptions{
+		"default":   DefaultTextureOptions(),
+		"pixel art": PixelArtTextureOptions(),
+		"ui":        UITextureOptions(),
+		"cubemap":   CubemapTextureOptions(),
+	} {
+		if err := opts.validate(); err != nil {
+			t.Errorf("%s: %v", name, err)
+		}
+	}
+
+	with := func(f func(*TextureOptions)) TextureOptions {
+		opts := DefaultTextureOptions()
+		f(&opts)
+		return opts
+	}
+	valid := []TextureOptions{
+		with(func(o *TextureOptions) { o.WrapS, o.WrapT = gl.MIRRORED_REPEAT, gl.CLAMP_TO_BORDER }),
+		with(func(o *TextureOptions) { o.MinFilter = gl.NEAREST_MIPMAP_LINEAR }),
+		with(func(o *TextureOptions) { o.MinFilter, o.Mipmaps = gl.NEAREST, false }),
+	}
+	for _, opts := range valid {
+		if err := opts.validate(); err != nil {
+			t.Errorf("%+v: %v", opts, err)
+		}
+	}
+
+	tests := []struct {
+		name string
+		opts TextureOptions
+		err  string
+	}{
+		{"no wrap S", with(func(o *TextureOptions) { o.WrapS = 0 }), "invalid wrap mode 0x0 for S"},
+		{"no wrap T", with(func(o *TextureOptions) { o.WrapT = 0 }), "invalid wrap mode 0x0 for T"},
+		{"filter as wrap", with(func(o *TextureOptions) { o.WrapT = gl.LINEAR }), "invalid wrap mode 0x2601 for T"},
+		{"no min filter", with(func(o *TextureOptions) { o.MinFilter = 0 }), "invalid min filter 0x0"},
+		{"wrap as min filter", with(func(o *TextureOptions) { o.MinFilter = gl.REPEAT }), "invalid min filter 0x2901"},
+		{"mipmaps missing", with(func(o *TextureOptions) { o.Mipmaps = false }), "min filter 0x2703 needs mipmaps"},
+		{"no mag filter", with(func(o *TextureOptions) { o.MagFilter = 0 }), "invalid mag filter 0x0"},
+		{"mipmap mag filter", with(func(o *TextureOptions) { o.MagFilter = gl.LINEAR_MIPMAP_LINEAR }), "invalid mag filter 0x2703"},
+		{"zero value", TextureOptions{}, "invalid wrap mode 0x0 for S"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.opts.validate(); err == nil || err.Error() != tt.err {
+				t.Errorf("got error %v, want %q", err, tt.err)
+			}
+		})
+	}
+}
+
+// Invalid options are rejected before anything reaches GL
+func TestLoadTextureImageRejectsOptions(t *testing.T) {
+	r, rec := testRenderer(t)
+	opts := DefaultTextureOptions()
+	opts.WrapS = 0
+	if _, err := r.LoadTextureImage(image.NewNRGBA(image.Rect(0, 0, 1, 1)), 0, opts); err == nil {
+		t.Fatal("a texture was loaded with an invalid wrap mode")
+	}
+	if cmds := rec.Commands(); len(cmds) != 0 {
+		t.Errorf("invalid options reached the backend: %v", cmds)
+	}
+}
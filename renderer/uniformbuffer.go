@@ -0,0 +1,104 @@
+package renderer
+
+import (
+	"math"
+
+	"github.com/go-gl/gl/v4.3-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// Binding points of the uniform blocks shared by all programs
+// Any program declaring one of these blocks gets it bound on load
+const (
+	FrameBinding  uint32 = 0
+	LightsBinding uint32 = 1
+)
+
+// Uniform block names, by binding point
+var uniformBlocks = map[string]uint32{
+	"Frame":  FrameBinding,
+	"Lights": LightsBinding,
+}
+
+// Number of floats of the std140 Frame block:
+// view, projection, and the camera position packed with the time
+const frameBlockSize = 16 + 16 + 4
+
+// A uniform buffer attached to a fixed binding point
+type UniformBuffer struct {
+	rendererID uint32 // A private ID for the object (e.g. OpenGL object ID)
+	binding    uint32 // Binding point the shaders read it from
+	size       int    // Allocated size in bytes
+}
+
+func NewUniformBuffer(binding uint32) *UniformBuffer {
+	ub := UniformBuffer{binding: binding}
+	backend.GenBuffers(1, &ub.rendererID)
+	return &ub
+}
+
+// Replaces the contents of the buffer
+// The data must already follow the std140 layout of the block
+func (ub *UniformBuffer) Upload(data []float32) {
+	size := len(data) * sizes[FLOAT]
+	backend.BindBuffer(gl.UNIFORM_BUFFER, ub.rendererID)
+	if size > ub.size {
+		backend.BufferData(gl.UNIFORM_BUFFER, size, gl.Ptr(data), gl.DYNAMIC_DRAW)
+		ub.size = size
+	} else if size > 0 {
+		backend.BufferSubData(gl.UNIFORM_BUFFER, 0, size, gl.Ptr(data))
+	}
+	backend.BindBuffer(gl.UNIFORM_BUFFER, 0)
+	ub.Bind()
+}
+
+// Attaches the buffer to its binding point
+func (ub *UniformBuffer) Bind() {
+	backend.BindBufferBase(gl.UNIFORM_BUFFER, ub.binding, ub.rendererID)
+}
+
+func (ub *UniformBuffer) Delete() {
+	backend.DeleteBuffers(1, &ub.rendererID)
+}
+
+// Points the uniform blocks a program declares to their shared binding points
+func bindUniformBlocks(s *Shader) {
+	for name, binding := range uniformBlocks {
+		idx := backend.GetUniformBlockIndex(s.rendererID, gl.Str(name+"\x00"))
+		if idx != gl.INVALID_INDEX {
+			backend.UniformBlockBinding(s.rendererID, idx, binding)
+		}
+		// reflect ran before the binding was set
+		if b, ok := s.blocks[name]; ok {
+			b.Binding = binding
+		}
+	}
+}
+
+// Uploads the per-frame data every program can read from the Frame block
+// The view matrix is also kept to sort the render queue
+func (r *Renderer) UploadFrame(view, proj mgl32.Mat4, viewPos mgl32.Vec3, time float32) {
+	if r.frame == nil {
+		r.frame = NewUniformBuffer(FrameBinding)
+	}
+	data := make([]float32, 0, frameBlockSize)
+	data = append(data, view[:]...)
+	data = append(data, proj[:]...)
+	data = append(data, viewPos[0], viewPos[1], viewPos[2], time)
+	r.frame.Upload(data)
+	r.view = view
+}
+
+// Uploads the Lights block, already packed following std140
+func (r *Renderer) UploadLights(data []float32) {
+	if r.lights == nil {
+		r.lights = NewUniformBuffer(LightsBinding)
+	}
+	r.lights.Upload(data)
+}
+
+// Returns the bits of an int as a float, so that it can be
+// uploaded along with floats into an int field of a block
+func IntBits(i int32) float32 {
+	return math.Float32frombits(uint32(i))
+}
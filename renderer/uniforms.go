@@ -0,0 +1,390 @@
+package renderer
+
+import (
+	"github.com/go-gl/gl/v4.3-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// Typed uniform setters
+// Every setter checks the uniform's type against the reflected one and
+// fails without touching GL on a mismatch, see Shader.location
+// Names are plain Go strings, a NUL terminator is only needed for GL
+// when a location is not cached yet
+
+// Sets a bool, GL takes them as ints
+func (s *Shader) SetBool(name string, v bool) error {
+	location, err := s.location(name, 1, gl.BOOL)
+	if err != nil {
+		return err
+	}
+	backend.Uniform1i(location, boolToInt(v))
+	return nil
+}
+
+func (s *Shader) SetInt(name string, v int32) error {
+	location, err := s.location(name, 1, gl.INT)
+	if err != nil {
+		return err
+	}
+	backend.Uniform1i(location, v)
+	return nil
+}
+
+func (s *Shader) SetUint(name string, v uint32) error {
+	location, err := s.location(name, 1, gl.UNSIGNED_INT)
+	if err != nil {
+		return err
+	}
+	backend.Uniform1ui(location, v)
+	return nil
+}
+
+func (s *Shader) SetFloat(name string, v float32) error {
+	location, err := s.location(name, 1, gl.FLOAT)
+	if err != nil {
+		return err
+	}
+	backend.Uniform1f(location, v)
+	return nil
+}
+
+func (s *Shader) SetVec2(name string, v mgl32.Vec2) error {
+	location, err := s.location(name, 1, gl.FLOAT_VEC2)
+	if err != nil {
+		return err
+	}
+	backend.Uniform2f(location, v[0], v[1])
+	return nil
+}
+
+func (s *Shader) SetVec3(name string, v mgl32.Vec3) error {
+	location, err := s.location(name, 1, gl.FLOAT_VEC3)
+	if err != nil {
+		return err
+	}
+	backend.Uniform3f(location, v[0], v[1], v[2])
+	return nil
+}
+
+func (s *Shader) SetVec4(name string, v mgl32.Vec4) error {
+	location, err := s.location(name, 1, gl.FLOAT_VEC4)
+	if err != nil {
+		return err
+	}
+	backend.Uniform4f(location, v[0], v[1], v[2], v[3])
+	return nil
+}
+
+func (s *Shader) SetIVec2(name string, v [2]int32) error {
+	location, err := s.location(name, 1, gl.INT_VEC2)
+	if err != nil {
+		return err
+	}
+	backend.Uniform2i(location, v[0], v[1])
+	return nil
+}
+
+func (s *Shader) SetIVec3(name string, v [3]int32) error {
+	location, err := s.location(name, 1, gl.INT_VEC3)
+	if err != nil {
+		return err
+	}
+	backend.Uniform3i(location, v[0], v[1], v[2])
+	return nil
+}
+
+func (s *Shader) SetIVec4(name string, v [4]int32) error {
+	location, err := s.location(name, 1, gl.INT_VEC4)
+	if err != nil {
+		return err
+	}
+	backend.Uniform4i(location, v[0], v[1], v[2], v[3])
+	return nil
+}
+
+func (s *Shader) SetUVec2(name string, v [2]uint32) error {
+	location, err := s.location(name, 1, gl.UNSIGNED_INT_VEC2)
+	if err != nil {
+		return err
+	}
+	backend.Uniform2ui(location, v[0], v[1])
+	return nil
+}
+
+func (s *Shader) SetUVec3(name string, v [3]uint32) error {
+	location, err := s.location(name, 1, gl.UNSIGNED_INT_VEC3)
+	if err != nil {
+		return err
+	}
+	backend.Uniform3ui(location, v[0], v[1], v[2])
+	return nil
+}
+
+func (s *Shader) SetUVec4(name string, v [4]uint32) error {
+	location, err := s.location(name, 1, gl.UNSIGNED_INT_VEC4)
+	if err != nil {
+		return err
+	}
+	backend.Uniform4ui(location, v[0], v[1], v[2], v[3])
+	return nil
+}
+
+func (s *Shader) SetMat2(name string, m mgl32.Mat2) error {
+	location, err := s.location(name, 1, gl.FLOAT_MAT2)
+	if err != nil {
+		return err
+	}
+	backend.UniformMatrix2fv(location, 1, false, &m[0])
+	return nil
+}
+
+func (s *Shader) SetMat3(name string, m mgl32.Mat3) error {
+	location, err := s.location(name, 1, gl.FLOAT_MAT3)
+	if err != nil {
+		return err
+	}
+	backend.UniformMatrix3fv(location, 1, false, &m[0])
+	return nil
+}
+
+func (s *Shader) SetMat4(name string, m mgl32.Mat4) error {
+	location, err := s.location(name, 1, gl.FLOAT_MAT4)
+	if err != nil {
+		return err
+	}
+	backend.UniformMatrix4fv(location, 1, false, &m[0])
+	return nil
+}
+
+// Binds a sampler to a texture unit, e.g. 0 for gl.TEXTURE0
+func (s *Shader) SetSampler(name string, unit uint32) error {
+	location, err := s.location(name, 1, anySampler)
+	if err != nil {
+		return err
+	}
+	backend.Uniform1i(location, int32(unit))
+	return nil
+}
+
+// Array setters
+// name may be an element (e.g. "lights[2]"), the values are then set from there on
+
+func (s *Shader) SetBools(name string, v []bool) error {
+	if len(v) == 0 {
+		return nil
+	}
+	location, err := s.location(name, len(v), gl.BOOL)
+	if err != nil {
+		return err
+	}
+	ints := make([]int32, len(v))
+	for i, b := range v {
+		ints[i] = boolToInt(b)
+	}
+	backend.Uniform1iv(location, int32(len(ints)), &ints[0])
+	return nil
+}
+
+func (s *Shader) SetInts(name string, v []int32) error {
+	if len(v) == 0 {
+		return nil
+	}
+	location, err := s.location(name, len(v), gl.INT)
+	if err != nil {
+		return err
+	}
+	backend.Uniform1iv(location, int32(len(v)), &v[0])
+	return nil
+}
+
+func (s *Shader) SetUints(name string, v []uint32) error {
+	if len(v) == 0 {
+		return nil
+	}
+	location, err := s.location(name, len(v), gl.UNSIGNED_INT)
+	if err != nil {
+		return err
+	}
+	backend.Uniform1uiv(location, int32(len(v)), &v[0])
+	return nil
+}
+
+func (s *Shader) SetFloats(name string, v []float32) error {
+	if len(v) == 0 {
+		return nil
+	}
+	location, err := s.location(name, len(v), gl.FLOAT)
+	if err != nil {
+		return err
+	}
+	backend.Uniform1fv(location, int32(len(v)), &v[0])
+	return nil
+}
+
+func (s *Shader) SetVec2s(name string, v []mgl32.Vec2) error {
+	if len(v) == 0 {
+		return nil
+	}
+	location, err := s.location(name, len(v), gl.FLOAT_VEC2)
+	if err != nil {
+		return err
+	}
+	backend.Uniform2fv(location, int32(len(v)), &v[0][0])
+	return nil
+}
+
+func (s *Shader) SetVec3s(name string, v []mgl32.Vec3) error {
+	if len(v) == 0 {
+		return nil
+	}
+	location, err := s.location(name, len(v), gl.FLOAT_VEC3)
+	if err != nil {
+		return err
+	}
+	backend.Uniform3fv(location, int32(len(v)), &v[0][0])
+	return nil
+}
+
+func (s *Shader) SetVec4s(name string, v []mgl32.Vec4) error {
+	if len(v) == 0 {
+		return nil
+	}
+	location, err := s.location(name, len(v), gl.FLOAT_VEC4)
+	if err != nil {
+		return err
+	}
+	backend.Uniform4fv(location, int32(len(v)), &v[0][0])
+	return nil
+}
+
+func (s *Shader) SetIVec2s(name string, v [][2]int32) error {
+	if len(v) == 0 {
+		return nil
+	}
+	location, err := s.location(name, len(v), gl.INT_VEC2)
+	if err != nil {
+		return err
+	}
+	backend.Uniform2iv(location, int32(len(v)), &v[0][0])
+	return nil
+}
+
+func (s *Shader) SetIVec3s(name string, v [][3]int32) error {
+	if len(v) == 0 {
+		return nil
+	}
+	location, err := s.location(name, len(v), gl.INT_VEC3)
+	if err != nil {
+		return err
+	}
+	backend.Uniform3iv(location, int32(len(v)), &v[0][0])
+	return nil
+}
+
+func (s *Shader) SetIVec4s(name string, v [][4]int32) error {
+	if len(v) == 0 {
+		return nil
+	}
+	location, err := s.location(name, len(v), gl.INT_VEC4)
+	if err != nil {
+		return err
+	}
+	backend.Uniform4iv(location, int32(len(v)), &v[0][0])
+	return nil
+}
+
+func (s *Shader) SetUVec2s(name string, v [][2]uint32) error {
+	if len(v) == 0 {
+		return nil
+	}
+	location, err := s.location(name, len(v), gl.UNSIGNED_INT_VEC2)
+	if err != nil {
+		return err
+	}
+	backend.Uniform2uiv(location, int32(len(v)), &v[0][0])
+	return nil
+}
+
+func (s *Shader) SetUVec3s(name string, v [][3]uint32) error {
+	if len(v) == 0 {
+		return nil
+	}
+	location, err := s.location(name, len(v), gl.UNSIGNED_INT_VEC3)
+	if err != nil {
+		return err
+	}
+	backend.Uniform3uiv(location, int32(len(v)), &v[0][0])
+	return nil
+}
+
+func (s *Shader) SetUVec4s(name string, v [][4]uint32) error {
+	if len(v) == 0 {
+		return nil
+	}
+	location, err := s.location(name, len(v), gl.UNSIGNED_INT_VEC4)
+	if err != nil {
+		return err
+	}
+	backend.Uniform4uiv(location, int32(len(v)), &v[0][0])
+	return nil
+}
+
+func (s *Shader) SetMat2s(name string, v []mgl32.Mat2) error {
+	if len(v) == 0 {
+		return nil
+	}
+	location, err := s.location(name, len(v), gl.FLOAT_MAT2)
+	if err != nil {
+		return err
+	}
+	backend.UniformMatrix2fv(location, int32(len(v)), false, &v[0][0])
+	return nil
+}
+
+func (s *Shader) SetMat3s(name string, v []mgl32.Mat3) error {
+	if len(v) == 0 {
+		return nil
+	}
+	location, err := s.location(name, len(v), gl.FLOAT_MAT3)
+	if err != nil {
+		return err
+	}
+	backend.UniformMatrix3fv(location, int32(len(v)), false, &v[0][0])
+	return nil
+}
+
+func (s *Shader) SetMat4s(name string, v []mgl32.Mat4) error {
+	if len(v) == 0 {
+		return nil
+	}
+	location, err := s.location(name, len(v), gl.FLOAT_MAT4)
+	if err != nil {
+		return err
+	}
+	backend.UniformMatrix4fv(location, int32(len(v)), false, &v[0][0])
+	return nil
+}
+
+// Samplers of an array, one texture unit each
+func (s *Shader) SetSamplers(name string, units []uint32) error {
+	if len(units) == 0 {
+		return nil
+	}
+	location, err := s.location(name, len(units), anySampler)
+	if err != nil {
+		return err
+	}
+	ints := make([]int32, len(units))
+	for i, u := range units {
+		ints[i] = int32(u)
+	}
+	backend.Uniform1iv(location, int32(len(ints)), &ints[0])
+	return nil
+}
+
+func boolToInt(b bool) int32 {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,234 @@
+package renderer
+
+import (
+	"bytes"
+	"log"
+	"math"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/go-gl/gl/v4.3-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// Installs a Recorder whose programs have the given active uniforms
+// The previous backend comes back when the test ends
+func useRecorder(t *testing.T, uniforms ...Uniform) *Recorder {
+	t.Helper()
+	rec := NewRecorder(uniforms...)
+	prev := CurrentBackend()
+	SetBackend(rec)
+	t.Cleanup(func() { SetBackend(prev) })
+	return rec
+}
+
+// Compiles an empty program against the recorder and forgets the calls that took
+func testShader(t *testing.T, rec *Recorder) *Shader {
+	t.Helper()
+	dir := shaderDir(t, map[string]string{
+		"test_vertex.glsl":   "#version 330 core\nvoid main() {}\n",
+		"test_fragment.glsl": "#version 330 core\nvoid main() {}\n",
+	})
+	s, err := NewShader(filepath.Join(dir, "test_vertex.glsl"), filepath.Join(dir, "test_fragment.glsl"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec.Reset()
+	return s
+}
+
+var testUniforms = []Uniform{
+	{Name: "model", Type: gl.FLOAT_MAT4},
+	{Name: "lights", Type: gl.FLOAT_VEC3, Size: 4},
+	{Name: "enabled", Type: gl.BOOL},
+	{Name: "shadowMaps", Type: gl.SAMPLER_2D, Size: 2},
+}
+
+func TestSetVec3s(t *testing.T) {
+	rec := useRecorder(t, testUniforms...)
+	s := testShader(t, rec)
+
+	v := []mgl32.Vec3{{1, 2, 3}, {4, 5, 6}}
+	if err := s.SetVec3s("lights[2]", v); err != nil {
+		t.Fatal(err)
+	}
+	sets := rec.UniformSets("lights[2]")
+	if len(sets) != 1 {
+		t.Fatalf("got %d calls setting lights[2], want 1: %v", len(sets), rec.Commands())
+	}
+	want := Command{Name: "Uniform3fv", Args: []interface{}{int32(2), []float32{1, 2, 3, 4, 5, 6}}, Uniform: "lights[2]"}
+	if !reflect.DeepEqual(sets[0], want) {
+		t.Errorf("got %v, want %v", sets[0], want)
+	}
+	// model takes location 0, so lights[2] is at 3
+	if loc := rec.uniformLocation("lights[2]"); loc != 3 {
+		t.Errorf("lights[2] is at location %d, want 3", loc)
+	}
+
+	// The whole array goes by its own name, without looking the location up
+	rec.Reset()
+	if err := s.SetVec3s("lights", make([]mgl32.Vec3, 4)); err != nil {
+		t.Fatal(err)
+	}
+	if got := rec.UniformSets("lights"); len(got) != 1 || got[0].Args[0] != int32(4) {
+		t.Errorf("got %v setting the whole array", got)
+	}
+	if got := rec.Calls("GetUniformLocation"); len(got) != 0 {
+		t.Errorf("looked up %v for the whole array", got)
+	}
+}
+
+func TestSetterTypeMismatch(t *testing.T) {
+	rec := useRecorder(t, testUniforms...)
+	s := testShader(t, rec)
+
+	tests := []struct {
+		name string
+		set  func() error
+		err  string
+	}{
+		{"vec4 on a vec3 array", func() error { return s.SetVec4s("lights", []mgl32.Vec4{{}}) }, `uniform "lights" is a vec3, not a vec4`},
+		{"mat3 on a mat4", func() error { return s.SetMat3("model", mgl32.Mat3{}) }, `uniform "model" is a mat4, not a mat3`},
+		{"int on a bool", func() error { return s.SetInt("enabled", 1) }, `uniform "enabled" is a bool, not a int`},
+		{"sampler on a bool", func() error { return s.SetSampler("enabled", 0) }, `uniform "enabled" is a bool, not a sampler`},
+		{"bool on a sampler", func() error { return s.SetBool("shadowMaps[1]", true) }, `uniform "shadowMaps[1]" is a sampler2D, not a bool`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec.Reset()
+			if err := tt.set(); err == nil || err.Error() != tt.err {
+				t.Errorf("got error %v, want %q", err, tt.err)
+			}
+			if cmds := rec.Commands(); len(cmds) != 0 {
+				t.Errorf("a mismatch reached the backend: %v", cmds)
+			}
+		})
+	}
+}
+
+func TestSetterArrayOverflow(t *testing.T) {
+	rec := useRecorder(t, testUniforms...)
+	s := testShader(t, rec)
+
+	tests := []struct {
+		name string
+		set  func() error
+		err  string
+	}{
+		{"too many elements", func() error { return s.SetVec3s("lights", make([]mgl32.Vec3, 5)) }, `setting 5 elements from "lights" overflows lights[4]`},
+		{"past the end from an element", func() error { return s.SetVec3s("lights[3]", make([]mgl32.Vec3, 2)) }, `setting 2 elements from "lights[3]" overflows lights[4]`},
+		{"element out of bounds", func() error { return s.SetVec3("lights[4]", mgl32.Vec3{}) }, `uniform "lights[4]" is out of the bounds of lights[4]`},
+		{"samplers", func() error { return s.SetSamplers("shadowMaps", []uint32{0, 1, 2}) }, `setting 3 elements from "shadowMaps" overflows shadowMaps[2]`},
+		{"scalar", func() error { return s.SetBools("enabled", []bool{true, false}) }, `setting 2 elements from "enabled" overflows enabled[1]`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec.Reset()
+			if err := tt.set(); err == nil || err.Error() != tt.err {
+				t.Errorf("got error %v, want %q", err, tt.err)
+			}
+			if cmds := rec.Commands(); len(cmds) != 0 {
+				t.Errorf("an overflow reached the backend: %v", cmds)
+			}
+		})
+	}
+
+	// The last elements still fit
+	rec.Reset()
+	if err := s.SetVec3s("lights[3]", make([]mgl32.Vec3, 1)); err != nil {
+		t.Fatal(err)
+	}
+	if got := rec.UniformSets("lights[3]"); len(got) != 1 {
+		t.Errorf("got %v setting the last element", got)
+	}
+}
+
+func TestSetterInactiveUniform(t *testing.T) {
+	rec := useRecorder(t, testUniforms...)
+	s := testShader(t, rec)
+
+	var out bytes.Buffer
+	prev := log.Writer()
+	log.SetOutput(&out)
+	defer log.SetOutput(prev)
+
+	for i := 0; i < 3; i++ {
+		if err := s.SetFloat("missing", 1); err == nil {
+			t.Fatal("setting an inactive uniform succeeded")
+		}
+	}
+	if n := strings.Count(out.String(), `uniform "missing" is not active`); n != 1 {
+		t.Errorf("warned %d times, want once:\n%s", n, out.String())
+	}
+	if cmds := rec.Commands(); len(cmds) != 0 {
+		t.Errorf("an inactive uniform reached the backend: %v", cmds)
+	}
+}
+
+func TestSplitElement(t *testing.T) {
+	tests := []struct {
+		name, array string
+		idx         int32
+		ok          bool
+	}{
+		{"lights[2]", "lights", 2, true},
+		{"lights[0]", "lights", 0, true},
+		{"pointLights[12].position[3]", "pointLights[12].position", 3, true},
+		{"lights[99999999999]", "lights", math.MaxInt32, true},
+		{"lights", "", 0, false},
+		{"lights[]", "", 0, false},
+		{"lights[-1]", "", 0, false},
+		{"lights[1]x", "", 0, false},
+		{"lights[x]", "", 0, false},
+		{"[1]", "", 0, false},
+	}
+	for _, tt := range tests {
+		array, idx, ok := splitElement(tt.name)
+		if array != tt.array || idx != tt.idx || ok != tt.ok {
+			t.Errorf("%q: got %q, %d, %v, want %q, %d, %v", tt.name, array, idx, ok, tt.array, tt.idx, tt.ok)
+		}
+	}
+}
+
+func TestLocationAllocations(t *testing.T) {
+	rec := useRecorder(t, testUniforms...)
+	s := testShader(t, rec)
+
+	// Every element is looked up once
+	for i := 0; i < 2; i++ {
+		loc, err := s.location("lights[2]", 1, gl.FLOAT_VEC3)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if loc != 3 {
+			t.Errorf("lights[2] is at location %d, want 3", loc)
+		}
+	}
+	if got := rec.Calls("GetUniformLocation"); len(got) != 1 {
+		t.Errorf("got %v looking up lights[2] twice", got)
+	}
+
+	lookups := []struct {
+		name string
+		typ  uint32
+	}{
+		{"model", gl.FLOAT_MAT4},
+		{"lights", gl.FLOAT_VEC3},
+		{"lights[2]", gl.FLOAT_VEC3},
+		{"shadowMaps[1]", anySampler},
+	}
+	for _, l := range lookups {
+		if _, err := s.location(l.name, 1, l.typ); err != nil {
+			t.Fatal(err)
+		}
+		allocs := testing.AllocsPerRun(100, func() {
+			s.location(l.name, 1, l.typ)
+			s.HasUniform(l.name)
+		})
+		if allocs != 0 {
+			t.Errorf("looking up %s allocates %v times", l.name, allocs)
+		}
+	}
+}
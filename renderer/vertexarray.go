@@ -2,41 +2,73 @@ package renderer
 
 import (
 	"github.com/go-gl/gl/v4.3-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
 )
 
+// Attribute locations of the per-instance data of instanced draws:
+// the model matrix takes four locations (one per column), the normal matrix three
+// They sit after every vertex Semantic so the two never overlap
+const (
+	InstanceModelLocation  uint32 = 8
+	InstanceNormalLocation uint32 = 12
+)
+
+// Floats per instance: a mat4 followed by a mat3
+const instanceSize = 16 + 9
+
 type VertexArray struct {
 	rendererID uint32
 	Vcount     int32 // vertex counter for draw call
 	DataSize   int32 // Size of input data
+	vb         *VertexBuffer
+	ib         *IndexBuffer // Optional index buffer, nil for non-indexed data
+	layout     *VertexBufferLayout
+	instances  *VertexBuffer // Per-instance model matrices, created by the first instanced draw
 }
 
 func NewVertexArray() *VertexArray {
 	va := VertexArray{}
-	gl.GenVertexArrays(1, &va.rendererID)
+	backend.GenVertexArrays(1, &va.rendererID)
 	return &va
 }
 
 func (va *VertexArray) DeleteVertexArray() {
-	gl.DeleteVertexArrays(1, &va.rendererID)
+	backend.DeleteVertexArrays(1, &va.rendererID)
+}
+
+// Deletes the vertex array along with the buffers attached to it
+func (va *VertexArray) Delete() {
+	va.DeleteVertexArray()
+	if va.vb != nil {
+		va.vb.Delete()
+	}
+	if va.ib != nil {
+		va.ib.Delete()
+	}
+	if va.instances != nil {
+		va.instances.Delete()
+	}
 }
 
 func (va *VertexArray) Bind() {
-	gl.BindVertexArray(va.rendererID)
+	backend.BindVertexArray(va.rendererID)
 }
 
 func (va *VertexArray) Unbind() {
-	gl.BindVertexArray(0)
+	backend.BindVertexArray(0)
 }
 
 func (va *VertexArray) AddBuffer(vb *VertexBuffer, vbl *VertexBufferLayout) {
 	va.Bind()
 	vb.Bind()
 
+	// Every attribute goes to the location of its semantic
 	offset := 0
-	for i, e := range vbl.Elements {
-		gl.EnableVertexAttribArray(uint32(i))
-		gl.VertexAttribPointer(
-			uint32(i),
+	for _, e := range vbl.Elements {
+		loc := e.semantic.Location()
+		backend.EnableVertexAttribArray(loc)
+		backend.VertexAttribPointer(
+			loc,
 			e.count,
 			uint32(e.etype),
 			e.normalized,
@@ -45,4 +77,80 @@ func (va *VertexArray) AddBuffer(vb *VertexBuffer, vbl *VertexBufferLayout) {
 		)
 		offset += int(e.count) * sizes[int(e.etype)]
 	}
+	va.vb = vb
+	va.layout = vbl
+}
+
+// Returns the layout of the vertex data, nil before AddBuffer
+func (va *VertexArray) Layout() *VertexBufferLayout {
+	return va.layout
+}
+
+// Attaches an index buffer to the vertex array
+// The ELEMENT_ARRAY_BUFFER binding is part of the VAO state, so the VAO
+// must be unbound before the index buffer, otherwise the binding is lost
+func (va *VertexArray) AddIndexBuffer(ib *IndexBuffer) {
+	va.Bind()
+	ib.Bind()
+	va.ib = ib
+}
+
+// Reports whether the vertex array is drawn with an index buffer
+func (va *VertexArray) Indexed() bool {
+	return va.ib != nil
+}
+
+// Uploads the model and normal matrices of an instanced draw, one of each per instance
+// The instance buffer and its attributes are set up on first use
+func (va *VertexArray) SetInstances(models []mgl32.Mat4, normals []mgl32.Mat3) {
+	va.Bind()
+	if va.instances == nil {
+		va.instances = &VertexBuffer{}
+		backend.GenBuffers(1, &va.instances.rendererID)
+		va.instances.Bind()
+
+		// Matrix attributes take one location per column
+		stride := int32(instanceSize * sizes[FLOAT])
+		for col := uint32(0); col < 4; col++ {
+			loc := InstanceModelLocation + col
+			backend.EnableVertexAttribArray(loc)
+			backend.VertexAttribPointer(loc, 4, gl.FLOAT, false, stride, gl.PtrOffset(int(col)*4*sizes[FLOAT]))
+			backend.VertexAttribDivisor(loc, 1)
+		}
+		for col := uint32(0); col < 3; col++ {
+			loc := InstanceNormalLocation + col
+			backend.EnableVertexAttribArray(loc)
+			backend.VertexAttribPointer(loc, 3, gl.FLOAT, false, stride, gl.PtrOffset((16+int(col)*3)*sizes[FLOAT]))
+			backend.VertexAttribDivisor(loc, 1)
+		}
+	}
+
+	data := make([]float32, 0, len(models)*instanceSize)
+	for i := range models {
+		data = append(data, models[i][:]...)
+		data = append(data, normals[i][:]...)
+	}
+	va.instances.Bind()
+	// Re-specifying the whole storage lets the driver orphan the previous frame's data
+	backend.BufferData(gl.ARRAY_BUFFER, len(data)*sizes[FLOAT], gl.Ptr(data), gl.STREAM_DRAW)
+}
+
+// Issues the draw call for the whole vertex array
+// The vertex array must be bound already
+func (va *VertexArray) Draw() {
+	if va.ib != nil {
+		backend.DrawElements(gl.TRIANGLES, va.ib.Count(), gl.UNSIGNED_INT, gl.PtrOffset(0))
+		return
+	}
+	backend.DrawArrays(gl.TRIANGLES, 0, va.DataSize/va.Vcount)
+}
+
+// Issues an instanced draw call, one instance per matrix given to SetInstances
+// The vertex array must be bound already
+func (va *VertexArray) DrawInstanced(count int32) {
+	if va.ib != nil {
+		backend.DrawElementsInstanced(gl.TRIANGLES, va.ib.Count(), gl.UNSIGNED_INT, gl.PtrOffset(0), count)
+		return
+	}
+	backend.DrawArraysInstanced(gl.TRIANGLES, 0, va.DataSize/va.Vcount, count)
 }
@@ -10,20 +10,20 @@ type VertexBuffer struct {
 
 func NewVertexBuffer(data []float32, size int) *VertexBuffer {
 	vb := VertexBuffer{}
-	gl.GenBuffers(1, &vb.rendererID)
-	gl.BindBuffer(gl.ARRAY_BUFFER, vb.rendererID)
-	gl.BufferData(gl.ARRAY_BUFFER, size, gl.Ptr(data), gl.STATIC_DRAW)
+	backend.GenBuffers(1, &vb.rendererID)
+	backend.BindBuffer(gl.ARRAY_BUFFER, vb.rendererID)
+	backend.BufferData(gl.ARRAY_BUFFER, size, gl.Ptr(data), gl.STATIC_DRAW)
 	return &vb
 }
 
 func (vb *VertexBuffer) Bind() {
-	gl.BindBuffer(gl.ARRAY_BUFFER, vb.rendererID)
+	backend.BindBuffer(gl.ARRAY_BUFFER, vb.rendererID)
 }
 
 func (vb *VertexBuffer) Unbind() {
-	gl.BindBuffer(gl.ARRAY_BUFFER, 0)
+	backend.BindBuffer(gl.ARRAY_BUFFER, 0)
 }
 
 func (vb *VertexBuffer) Delete() {
-	gl.DeleteBuffers(1, &vb.rendererID)
+	backend.DeleteBuffers(1, &vb.rendererID)
 }
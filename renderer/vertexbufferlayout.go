@@ -1,11 +1,57 @@
 package renderer
 
-import "github.com/go-gl/gl/v4.3-core/gl"
+import (
+	"fmt"
+
+	"github.com/go-gl/gl/v4.3-core/gl"
+)
+
+// Semantic names what a vertex attribute holds
+// Every semantic is bound to a fixed attribute location (its value), so any
+// shader declaring e.g. `layout(location = 2) in vec3 aNormal` can be used
+// with any mesh that carries normals, whatever else the mesh contains
+type Semantic uint32
+
+const (
+	Position  Semantic = iota // location 0, vec3
+	TexCoord0                 // location 1, vec2
+	Normal                    // location 2, vec3
+	Tangent                   // location 3, vec3 or vec4 (w holds handedness)
+	Color                     // location 4, vec3 or vec4
+	TexCoord1                 // location 5, vec2
+
+	semanticCount
+)
+
+var semanticNames = [semanticCount]string{"position", "texcoord0", "normal", "tangent", "color", "texcoord1"}
+
+// Allowed component counts for every semantic
+var semanticCounts = [semanticCount][]int32{
+	{3},
+	{2},
+	{3},
+	{3, 4},
+	{3, 4},
+	{2},
+}
+
+func (s Semantic) String() string {
+	if s < semanticCount {
+		return semanticNames[s]
+	}
+	return fmt.Sprintf("semantic(%d)", uint32(s))
+}
+
+// Returns the attribute location the semantic is bound to
+func (s Semantic) Location() uint32 {
+	return uint32(s)
+}
 
 type VertexBufferElement struct {
 	count      int32
 	etype      uint32
 	normalized bool
+	semantic   Semantic
 }
 
 type VertexBufferLayout struct {
@@ -14,24 +60,92 @@ type VertexBufferLayout struct {
 	Vcount   int32
 }
 
-func (vbl *VertexBufferLayout) PushFloat(count int32) {
+// Returns the position/texture/normal layout every default shader expects
+func DefaultLayout() *VertexBufferLayout {
+	vbl := new(VertexBufferLayout)
+	vbl.Push(Position, 3)  // position: a fvec3
+	vbl.Push(TexCoord0, 2) // texture: a fvec2
+	vbl.Push(Normal, 3)    // Normals: a fvec3
+	return vbl
+}
+
+// Pushes a float attribute for the given semantic
+func (vbl *VertexBufferLayout) Push(semantic Semantic, count int32) {
 	e := VertexBufferElement{
 		etype:      gl.FLOAT,
 		count:      count,
 		normalized: false,
+		semantic:   semantic,
 	}
 	vbl.Elements = append(vbl.Elements, e)
 	vbl.Stride += count * int32(sizes[FLOAT])
 	vbl.Vcount += count // This is used to measure triangles at the end
 }
 
-func (vbl *VertexBufferLayout) PushUint(count int32) {
-	e := VertexBufferElement{
-		etype:      gl.UNSIGNED_INT,
-		count:      count,
-		normalized: false,
+// Pushes an unnamed float attribute
+// Its semantic is derived from its position in the layout
+func (vbl *VertexBufferLayout) PushFloat(count int32) {
+	vbl.Push(Semantic(len(vbl.Elements)), count)
+}
+
+// Reports whether the layout carries an attribute for the semantic
+func (vbl *VertexBufferLayout) Has(semantic Semantic) bool {
+	for _, e := range vbl.Elements {
+		if e.semantic == semantic {
+			return true
+		}
 	}
-	vbl.Elements = append(vbl.Elements, e)
-	vbl.Stride += count * int32(sizes[UINT32])
-	vbl.Vcount += count // This is used to measure triangles at the end
+	return false
+}
+
+// Checks the layout itself and that dataLen (in components) holds
+// a whole number of vertices
+func (vbl *VertexBufferLayout) Validate(dataLen int) error {
+	if len(vbl.Elements) == 0 {
+		return fmt.Errorf("empty vertex buffer layout")
+	}
+	seen := make(map[Semantic]bool, len(vbl.Elements))
+	for _, e := range vbl.Elements {
+		if e.semantic >= semanticCount {
+			return fmt.Errorf("unknown vertex semantic: %v", e.semantic)
+		}
+		if e.etype != gl.FLOAT {
+			return fmt.Errorf("vertex semantic %v must hold floats", e.semantic)
+		}
+		if seen[e.semantic] {
+			return fmt.Errorf("vertex semantic %v appears more than once", e.semantic)
+		}
+		seen[e.semantic] = true
+
+		valid := false
+		for _, c := range semanticCounts[e.semantic] {
+			if c == e.count {
+				valid = true
+			}
+		}
+		if !valid {
+			return fmt.Errorf("vertex semantic %v cannot have %d components", e.semantic, e.count)
+		}
+	}
+	if !seen[Position] {
+		return fmt.Errorf("vertex buffer layout has no position")
+	}
+	if dataLen == 0 || dataLen%int(vbl.Vcount) != 0 {
+		return fmt.Errorf("data length %d is not a multiple of the vertex size %d", dataLen, vbl.Vcount)
+	}
+	return nil
+}
+
+// Checks that the layout provides every per-vertex attribute the program reads
+// Attributes at the instance locations are filled in by SetInstances instead
+func (vbl *VertexBufferLayout) Supports(s *Shader) error {
+	for _, a := range s.attributes {
+		if a.Location < 0 || uint32(a.Location) >= InstanceModelLocation {
+			continue
+		}
+		if !vbl.Has(Semantic(a.Location)) {
+			return fmt.Errorf("program reads %q at location %d but the mesh has no %v", a.Name, a.Location, Semantic(a.Location))
+		}
+	}
+	return nil
 }
@@ -0,0 +1,69 @@
+package renderer
+
+import (
+	"bufio"
+	"fmt"
+	"image"
+	"os"
+)
+
+// Writes frames as an uncompressed YUV4MPEG2 stream
+// Chroma is kept at full resolution (4:4:4) and converted with BT.601 in limited range,
+// which is what decoders assume when the header doesn't say otherwise
+type y4mWriter struct {
+	f             *os.File
+	w             *bufio.Writer
+	fps           int
+	width, height int // Set by the first frame, which writes the header
+	planes        []byte
+}
+
+func newY4MWriter(path string, fps int) (*y4mWriter, error) {
+	if fps <= 0 {
+		return nil, fmt.Errorf("invalid frame rate: %d", fps)
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not create video file: %v", err)
+	}
+	return &y4mWriter{f: f, w: bufio.NewWriter(f), fps: fps}, nil
+}
+
+func (y *y4mWriter) writeFrame(img *image.NRGBA) error {
+	width, height := img.Rect.Dx(), img.Rect.Dy()
+	if y.planes == nil {
+		y.width, y.height = width, height
+		y.planes = make([]byte, 3*width*height)
+		if _, err := fmt.Fprintf(y.w, "YUV4MPEG2 W%d H%d F%d:1 Ip A1:1 C444\n", width, height, y.fps); err != nil {
+			return err
+		}
+	}
+	if width != y.width || height != y.height {
+		return fmt.Errorf("frame is %dx%d, the stream is %dx%d", width, height, y.width, y.height)
+	}
+
+	n := width * height
+	for row := 0; row < height; row++ {
+		for col := 0; col < width; col++ {
+			p := img.Pix[row*img.Stride+col*4:]
+			r, g, b := float32(p[0]), float32(p[1]), float32(p[2])
+			i := row*width + col
+			y.planes[i] = uint8(16 + (65.481*r+128.553*g+24.966*b)/255 + 0.5)
+			y.planes[n+i] = uint8(128 + (-37.797*r-74.203*g+112*b)/255 + 0.5)
+			y.planes[2*n+i] = uint8(128 + (112*r-93.786*g-18.214*b)/255 + 0.5)
+		}
+	}
+	if _, err := y.w.WriteString("FRAME\n"); err != nil {
+		return err
+	}
+	_, err := y.w.Write(y.planes)
+	return err
+}
+
+func (y *y4mWriter) close() error {
+	if err := y.w.Flush(); err != nil {
+		y.f.Close()
+		return err
+	}
+	return y.f.Close()
+}
@@ -6,9 +6,14 @@ layout(location = 2) in vec3 aNormal;
 out vec2 TexCoord;
 out vec3 ourColor;
 
+#ifdef INSTANCED
+// Per-instance model matrix, takes locations 8 to 11
+layout(location = 8) in mat4 model;
+#else
 uniform mat4 model;
-uniform mat4 projection;
-uniform mat4 view;
+#endif
+
+#include "frame.glsl"
 
 void main()
 {
@@ -0,0 +1,7 @@
+// Per-frame data shared by all programs, see renderer.UploadFrame
+layout(std140) uniform Frame {
+	mat4 view;
+	mat4 projection;
+	vec3 viewPos;
+	float time;
+};
@@ -1,9 +1,14 @@
 #version 330 core
 layout (location = 0) in vec3 aPos;
 
+#ifdef INSTANCED
+// Per-instance model matrix, takes locations 8 to 11
+layout(location = 8) in mat4 model;
+#else
 uniform mat4 model;
-uniform mat4 view;
-uniform mat4 projection;
+#endif
+
+#include "frame.glsl"
 
 void main()
 {
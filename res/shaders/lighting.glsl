@@ -0,0 +1,129 @@
+// Phong lighting shared by lit fragment shaders
+// Needs #version 430 for the point light storage buffer
+// Callers set specularMask before calling any of the functions
+
+struct Material {
+	vec3 ambient;
+	vec3 diffuse;
+	vec3 specular;
+	vec3 emissive;
+	float shininess;
+	float opacity;
+
+	bool hasDiffuseMap;
+	bool hasSpecularMap;
+	bool hasNormalMap;
+	bool hasEmissiveMap;
+};
+
+struct DirLight {
+    vec3 direction;
+
+    vec3 ambient;
+    vec3 diffuse;
+    vec3 specular;
+};
+
+// Mirrors the std430 packing done in scene.PointLight
+struct PointLight {
+    vec4 position;    // xyz
+    vec4 ambient;     // rgb
+    vec4 diffuse;     // rgb
+    vec4 specular;    // rgb
+    vec4 attenuation; // constant, linear, quadratic
+};
+
+// Scalars fill the padding after each vec3, see scene.SpotLight.pack
+struct SpotLight {
+    vec3 position;
+    float cutOff;
+    vec3 direction;
+    float outerCutOff;
+
+    vec3 ambient;
+    float constant;
+    vec3 diffuse;
+    float linear;
+    vec3 specular;
+    float quadratic;
+};
+
+// Filled by scene.Scene.Update
+layout(std140) uniform Lights {
+    DirLight dirLight;
+    SpotLight spotLight;
+    int nrPointLights;
+};
+layout(std430, binding = 0) readonly buffer PointLights {
+    PointLight pointLights[];
+};
+
+uniform Material material;
+
+// Per-fragment specular intensity, read from the specular map if there is one
+vec3 specularMask;
+
+vec3 CalcDirLight(DirLight light, vec3 normal, vec3 viewDir)
+{
+	vec3 lightDir = normalize(-light.direction);
+    // diffuse shading
+	// NOTE: dot product could produce negative cosine value
+	// and that would result in weird artifacts
+    float diff = max(dot(normal, lightDir), 0.0);
+    // specular shading
+    vec3 reflectDir = reflect(-lightDir, normal);
+    float spec = pow(max(dot(viewDir, reflectDir), 0.0), material.shininess);
+    // combine results
+    vec3 ambient = light.ambient * material.ambient;
+    vec3 diffuse = light.diffuse * (diff * material.diffuse);
+    vec3 specular = light.specular * (spec * material.specular * specularMask);
+    return (ambient + diffuse + specular);
+}
+
+vec3 CalcPointLight(PointLight light, vec3 normal, vec3 fragPos, vec3 viewDir)
+{
+    vec3 lightDir = normalize(light.position.xyz - fragPos);
+    // diffuse shading
+    float diff = max(dot(normal, lightDir), 0.0);
+    // specular shading
+    vec3 reflectDir = reflect(-lightDir, normal);
+    float spec = pow(max(dot(viewDir, reflectDir), 0.0), material.shininess);
+    // attenuation
+    float distance = length(light.position.xyz - fragPos);
+    vec3 att = light.attenuation.xyz;
+    float attenuation = 1.0 / (att.x + att.y * distance + att.z * (distance * distance));
+    // combine results
+    vec3 ambient = light.ambient.rgb * material.ambient;
+    vec3 diffuse = light.diffuse.rgb * diff * material.diffuse;
+    vec3 specular = light.specular.rgb * spec * material.specular * specularMask;
+    ambient *= attenuation;
+    diffuse *= attenuation;
+    specular *= attenuation;
+    return (ambient + diffuse + specular);
+}
+
+// calculates the color when using a spot light.
+vec3 CalcSpotLight(SpotLight light, vec3 normal, vec3 fragPos, vec3 viewDir)
+{
+    vec3 lightDir = normalize(light.position - fragPos);
+    // diffuse shading
+    float diff = max(dot(normal, lightDir), 0.0);
+    // specular shading
+    vec3 reflectDir = reflect(-lightDir, normal);
+    float spec = pow(max(dot(viewDir, reflectDir), 0.0), material.shininess);
+    // attenuation
+    float distance = length(light.position - fragPos);
+    float attenuation = 1.0 / (light.constant + light.linear * distance + light.quadratic * (distance * distance));
+    // spotlight intensity
+    float theta = dot(lightDir, normalize(-light.direction));
+    float epsilon = light.cutOff - light.outerCutOff;
+    float intensity = clamp((theta - light.outerCutOff) / epsilon, 0.0, 1.0);
+    // combine results
+    vec3 ambient = light.ambient * material.ambient;
+    vec3 diffuse = light.diffuse * diff * material.diffuse;
+    vec3 specular = light.specular * spec * material.specular * specularMask;
+    ambient *= attenuation * intensity;
+    diffuse *= attenuation * intensity;
+    specular *= attenuation * intensity;
+    return (ambient + diffuse + specular);
+}
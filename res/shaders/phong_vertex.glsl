@@ -7,14 +7,21 @@ out vec3 FragPos;
 out vec3 Normal;
 out vec2 TexCoord;
 
+#ifdef INSTANCED
+// Per-instance model and normal matrices, take locations 8 to 11 and 12 to 14
+layout(location = 8) in mat4 model;
+layout(location = 12) in mat3 normalMatrix;
+#else
 uniform mat4 model;
-uniform mat4 view;
-uniform mat4 projection;
+uniform mat3 normalMatrix; // Inverse transpose of model, computed on the CPU
+#endif
+
+#include "frame.glsl"
 
 void main()
 {
     FragPos = vec3(model * vec4(position, 1.0));
-    Normal = mat3(transpose(inverse(model))) * aNormal;  
+    Normal = normalMatrix * aNormal;
 
     gl_Position = projection * view * vec4(FragPos, 1.0);
 	TexCoord = aTexCoord;
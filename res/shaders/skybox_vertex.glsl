@@ -0,0 +1,15 @@
+#version 330 core
+layout(location = 0) in vec3 position;
+
+out vec3 TexCoord;
+
+#include "frame.glsl"
+
+void main()
+{
+	TexCoord = position;
+	// Only the rotation of the camera, the sky never gets any closer
+	vec4 pos = projection * mat4(mat3(view)) * vec4(position, 1.0);
+	// Depth is always 1, so the sky is behind everything else
+	gl_Position = pos.xyww;
+}
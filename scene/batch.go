@@ -0,0 +1,43 @@
+package scene
+
+import (
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/linosgian/goph3d/renderer"
+)
+
+// Nodes sharing a mesh and a material, as created by NewNodes
+// A batch is drawn with a single instanced draw call
+type Batch struct {
+	Name     string
+	Mesh     *renderer.Mesh
+	Material *Material
+	Nodes    []*Node
+
+	models  []mgl32.Mat4 // Reused between frames
+	normals []mgl32.Mat3
+}
+
+// Returns the world and normal matrices of all renderable nodes of the batch
+// The returned slices are only valid until the next call
+func (b *Batch) ModelMatrices() ([]mgl32.Mat4, []mgl32.Mat3) {
+	b.models = b.models[:0]
+	b.normals = b.normals[:0]
+	for _, n := range b.Nodes {
+		if n.Renderable {
+			b.models = append(b.models, n.ModelMatrix())
+			b.normals = append(b.normals, n.NormalMatrix())
+		}
+	}
+	return b.models, b.normals
+}
+
+// Removes a node from the batch
+func (b *Batch) remove(n *Node) {
+	for i, bn := range b.Nodes {
+		if bn == n {
+			b.Nodes = append(b.Nodes[:i], b.Nodes[i+1:]...)
+			break
+		}
+	}
+	n.Batch = nil
+}
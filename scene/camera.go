@@ -58,6 +58,15 @@ func (c *Camera) GetViewMatrix() mgl32.Mat4 {
 	return mgl32.LookAtV(c.Position, c.Position.Add(c.Front), c.Up)
 }
 
+// Turns the camera to look along a direction
+// Roll is not supported, the camera stays level with WorldUp
+func (c *Camera) LookAlong(dir mgl32.Vec3) {
+	dir = dir.Normalize()
+	c.Pitch = mgl32.RadToDeg(float32(math.Asin(float64(dir.Y()))))
+	c.Yaw = mgl32.RadToDeg(float32(math.Atan2(float64(dir.Z()), float64(dir.X()))))
+	c.updateCameraVectors()
+}
+
 func (c *Camera) ProcessMouseMovement(xoffset, yoffset float32) {
 	xoffset *= c.MouseSensitivity
 	yoffset *= c.MouseSensitivity
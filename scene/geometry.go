@@ -0,0 +1,25 @@
+package scene
+
+import (
+	"github.com/linosgian/goph3d/renderer"
+)
+
+// Geometry is what a node is drawn from: either raw vertex data to upload
+// (RawGeometry) or a mesh that is already loaded (*renderer.Mesh)
+// Acquire returns the mesh holding a reference for the caller
+type Geometry interface {
+	Acquire(r *renderer.Renderer) (*renderer.Mesh, error)
+}
+
+// RawGeometry is vertex data uploaded as a new mesh
+// A non-empty Name lets other nodes share the mesh through Renderer.Mesh
+type RawGeometry struct {
+	Name     string
+	Vertices []float32
+	Indices  []uint32                     // nil for non-indexed data
+	Layout   *renderer.VertexBufferLayout // nil for the default layout
+}
+
+func (g RawGeometry) Acquire(r *renderer.Renderer) (*renderer.Mesh, error) {
+	return r.LoadMesh(g.Name, g.Vertices, g.Indices, g.Layout)
+}
@@ -0,0 +1,187 @@
+package scene
+
+import (
+	"fmt"
+	"log"
+	"sort"
+
+	"github.com/go-gl/gl/v4.3-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/linosgian/goph3d/renderer"
+)
+
+// Material describes the look of a node: the program it is drawn with,
+// its texture maps, its colors and the render state
+// Texture maps hold internal texture IDs, renderer.NoTexture when unused
+type Material struct {
+	Name    string
+	Program int
+
+	DiffuseMap, SpecularMap, NormalMap, EmissiveMap int
+	Textures                                        map[string]int // Any other texture, by sampler name, e.g. a texture array
+
+	Ambient, Diffuse, Specular, Emissive mgl32.Vec3
+	Shininess                            float32
+	Opacity                              float32
+
+	State renderer.RenderState
+
+	warned bool // A texture binding error was logged already
+}
+
+// Creates a plain white material drawn by a program (e.g. "phong", "lamp")
+// The diffuse map is loaded from diffusePath, unless the path is empty
+// The caller owns the material, see Release
+func NewMaterial(r *renderer.Renderer, programName, diffusePath string) (*Material, error) {
+	programID, err := r.GetProgram(programName)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &Material{
+		Name:        programName,
+		Program:     programID,
+		DiffuseMap:  renderer.NoTexture,
+		SpecularMap: renderer.NoTexture,
+		NormalMap:   renderer.NoTexture,
+		EmissiveMap: renderer.NoTexture,
+		Ambient:     mgl32.Vec3{1, 1, 1},
+		Diffuse:     mgl32.Vec3{1, 1, 1},
+		Specular:    mgl32.Vec3{0.5, 0.5, 0.5},
+		Shininess:   32,
+		Opacity:     1,
+		State:       renderer.DefaultRenderState(),
+	}
+	if diffusePath != "" {
+		texID, err := r.LoadTexture(diffusePath, programID, renderer.DefaultTextureOptions())
+		if err != nil {
+			return nil, err
+		}
+		m.DiffuseMap = texID
+	}
+	return m, nil
+}
+
+func (m *Material) ProgramID() int {
+	return m.Program
+}
+
+func (m *Material) TextureID() int {
+	return m.DiffuseMap
+}
+
+func (m *Material) RenderState() renderer.RenderState {
+	return m.State
+}
+
+// Checks the material against the uniforms of its program, so that a
+// mismatch shows up when a node is created rather than on every draw
+// Every extra texture needs an active sampler, and the uniforms Apply sets
+// must have the types it sets them with
+func (m *Material) Validate(r *renderer.Renderer) error {
+	s := r.Programs[m.Program]
+	for name := range m.Textures {
+		if err := s.CheckSampler(name); err != nil {
+			return fmt.Errorf("material %q: %v", m.Name, err)
+		}
+	}
+
+	uniforms := []struct {
+		name string
+		typ  uint32
+	}{
+		{"aTexture", gl.SAMPLER_2D},
+		{"specularMap", gl.SAMPLER_2D},
+		{"normalMap", gl.SAMPLER_2D},
+		{"emissiveMap", gl.SAMPLER_2D},
+		{"material.ambient", gl.FLOAT_VEC3},
+		{"material.diffuse", gl.FLOAT_VEC3},
+		{"material.specular", gl.FLOAT_VEC3},
+		{"material.emissive", gl.FLOAT_VEC3},
+		{"material.shininess", gl.FLOAT},
+		{"material.opacity", gl.FLOAT},
+		{"material.hasDiffuseMap", gl.BOOL},
+		{"material.hasSpecularMap", gl.BOOL},
+		{"material.hasNormalMap", gl.BOOL},
+		{"material.hasEmissiveMap", gl.BOOL},
+	}
+	for _, u := range uniforms {
+		// Programs only declare what they use, see Apply
+		if !s.HasUniform(u.name) {
+			continue
+		}
+		if err := s.CheckUniform(u.name, u.typ); err != nil {
+			return fmt.Errorf("material %q: %v", m.Name, err)
+		}
+	}
+	return nil
+}
+
+// Uploads the material to the (already bound) program
+// Uniforms the program does not use are silently skipped
+func (m *Material) Apply(r *renderer.Renderer, s *renderer.Shader) {
+	maps := []struct {
+		sampler, flag string
+		texID         int
+	}{
+		{"aTexture", "material.hasDiffuseMap", m.DiffuseMap},
+		{"specularMap", "material.hasSpecularMap", m.SpecularMap},
+		{"normalMap", "material.hasNormalMap", m.NormalMap},
+		{"emissiveMap", "material.hasEmissiveMap", m.EmissiveMap},
+	}
+	bindings := make([]renderer.TextureBinding, 0, len(maps)+len(m.Textures))
+	for _, tm := range maps {
+		if s.HasUniform(tm.flag) {
+			s.SetBool(tm.flag, tm.texID != renderer.NoTexture)
+		}
+		// Programs only declare the maps they use
+		if !s.HasUniform(tm.sampler) {
+			continue
+		}
+		bindings = append(bindings, renderer.TextureBinding{Sampler: tm.sampler, TexID: tm.texID})
+	}
+	// Sorted so that every sampler keeps its unit from one draw to the next
+	samplers := make([]string, 0, len(m.Textures))
+	for name := range m.Textures {
+		samplers = append(samplers, name)
+	}
+	sort.Strings(samplers)
+	for _, name := range samplers {
+		bindings = append(bindings, renderer.TextureBinding{Sampler: name, TexID: m.Textures[name]})
+	}
+	// Validate catches most mismatches up front, anything else is only logged once
+	if err := r.BindTextures(s, bindings); err != nil && !m.warned {
+		m.warned = true
+		log.Printf("material %q: %v", m.Name, err)
+	}
+
+	// Unlit programs (e.g. lamp) have no material block
+	if !s.HasUniform("material.diffuse") {
+		return
+	}
+	s.SetVec3("material.ambient", m.Ambient)
+	s.SetVec3("material.diffuse", m.Diffuse)
+	s.SetVec3("material.specular", m.Specular)
+	s.SetVec3("material.emissive", m.Emissive)
+	s.SetFloat("material.shininess", m.Shininess)
+	s.SetFloat("material.opacity", m.Opacity)
+}
+
+// Gives back the material's references to its texture maps
+// A material belongs to whoever created it (e.g. NewMaterial or a loader's caller),
+// nodes only point at it, so removing them leaves the material alone.
+// Release it once no node of any scene is drawn with it, it must not be drawn afterwards
+func (m *Material) Release(r *renderer.Renderer) {
+	for _, texID := range []*int{&m.DiffuseMap, &m.SpecularMap, &m.NormalMap, &m.EmissiveMap} {
+		if *texID != renderer.NoTexture {
+			r.ReleaseTexture(*texID)
+			*texID = renderer.NoTexture
+		}
+	}
+	for name, texID := range m.Textures {
+		if texID != renderer.NoTexture {
+			r.ReleaseTexture(texID)
+		}
+		delete(m.Textures, name)
+	}
+}
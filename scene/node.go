@@ -0,0 +1,189 @@
+package scene
+
+import (
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/linosgian/goph3d/renderer"
+)
+
+// Holds the internal VAO ID and the material a node is drawn with,
+// alongside its place in the scene graph
+// A node's transform is local to its parent, the world matrix is
+// computed lazily and only when the node or one of its ancestors moved
+type Node struct {
+	VaoID      int
+	Mesh       *renderer.Mesh // The mesh VaoID belongs to, nil for groups
+	Material   *Material
+	Renderable bool
+	Name       string
+	Batch      *Batch // Set when the node is drawn instanced along with others
+
+	Parent   *Node
+	Children []*Node
+
+	// Local TRS transform
+	translation mgl32.Vec3
+	rotation    mgl32.Quat
+	scale       mgl32.Vec3
+
+	local  mgl32.Mat4 // Cached local matrix, always in sync with the TRS
+	world  mgl32.Mat4 // Cached world matrix, valid when dirty is false
+	normal mgl32.Mat3 // Cached normal matrix of world, valid when dirty is false
+	dirty  bool
+}
+
+// Creates a node with an identity transform
+func NewEmptyNode(name string) *Node {
+	return &Node{
+		Name:     name,
+		rotation: mgl32.QuatIdent(),
+		scale:    mgl32.Vec3{1, 1, 1},
+		local:    mgl32.Ident4(),
+		dirty:    true,
+	}
+}
+
+func (n *Node) setMesh(m *renderer.Mesh) {
+	n.Mesh = m
+	n.VaoID = m.VaoID
+}
+
+func (n *Node) Translation() mgl32.Vec3 {
+	return n.translation
+}
+
+func (n *Node) Rotation() mgl32.Quat {
+	return n.rotation
+}
+
+func (n *Node) Scale() mgl32.Vec3 {
+	return n.scale
+}
+
+func (n *Node) SetTranslation(t mgl32.Vec3) {
+	n.translation = t
+	n.updateLocal()
+}
+
+func (n *Node) SetRotation(q mgl32.Quat) {
+	n.rotation = q
+	n.updateLocal()
+}
+
+func (n *Node) SetScale(s mgl32.Vec3) {
+	n.scale = s
+	n.updateLocal()
+}
+
+// Sets all three parts of the local transform at once
+func (n *Node) SetTRS(t mgl32.Vec3, q mgl32.Quat, s mgl32.Vec3) {
+	n.translation, n.rotation, n.scale = t, q, s
+	n.updateLocal()
+}
+
+// Sets the local transform from a matrix
+// The matrix is decomposed into TRS, so any shear is lost
+func (n *Node) SetModelMatrix(model mgl32.Mat4) {
+	n.translation = model.Col(3).Vec3()
+
+	cols := [3]mgl32.Vec3{model.Col(0).Vec3(), model.Col(1).Vec3(), model.Col(2).Vec3()}
+	n.scale = mgl32.Vec3{cols[0].Len(), cols[1].Len(), cols[2].Len()}
+
+	rot := mgl32.Ident4()
+	for c := 0; c < 3; c++ {
+		if n.scale[c] == 0 {
+			continue
+		}
+		col := cols[c].Mul(1 / n.scale[c])
+		rot.Set(0, c, col[0])
+		rot.Set(1, c, col[1])
+		rot.Set(2, c, col[2])
+	}
+	n.rotation = mgl32.Mat4ToQuat(rot)
+
+	n.local = model
+	n.markDirty()
+}
+
+// Returns the local transform matrix
+func (n *Node) LocalMatrix() mgl32.Mat4 {
+	return n.local
+}
+
+// Returns the world (model) matrix of the node,
+// recomputing it and its ancestors' if any of them moved
+func (n *Node) ModelMatrix() mgl32.Mat4 {
+	if n.dirty {
+		if n.Parent != nil {
+			n.world = n.Parent.ModelMatrix().Mul4(n.local)
+		} else {
+			n.world = n.local
+		}
+		n.normal = renderer.NormalMatrix(n.world)
+		n.dirty = false
+	}
+	return n.world
+}
+
+// Returns the matrix transforming the node's normals to world space,
+// kept up to date along with the world matrix
+func (n *Node) NormalMatrix() mgl32.Mat3 {
+	n.ModelMatrix()
+	return n.normal
+}
+
+// Returns the position of the node in world space
+func (n *Node) WorldPosition() mgl32.Vec3 {
+	return n.ModelMatrix().Col(3).Vec3()
+}
+
+// Attaches a child to the node, detaching it from its previous parent
+// The child keeps its local transform, so it now moves along with n
+func (n *Node) AddChild(c *Node) {
+	if c.Parent != nil {
+		c.Parent.RemoveChild(c)
+	}
+	c.Parent = n
+	n.Children = append(n.Children, c)
+	c.markDirty()
+}
+
+// Detaches a child from the node
+// Returns false if c is not a child of n
+func (n *Node) RemoveChild(c *Node) bool {
+	for i, child := range n.Children {
+		if child == c {
+			n.Children = append(n.Children[:i], n.Children[i+1:]...)
+			c.Parent = nil
+			c.markDirty()
+			return true
+		}
+	}
+	return false
+}
+
+// Calls fn for the node and all of its descendants, parents first
+func (n *Node) Walk(fn func(*Node)) {
+	fn(n)
+	for _, c := range n.Children {
+		c.Walk(fn)
+	}
+}
+
+func (n *Node) updateLocal() {
+	t := mgl32.Translate3D(n.translation.X(), n.translation.Y(), n.translation.Z())
+	s := mgl32.Scale3D(n.scale.X(), n.scale.Y(), n.scale.Z())
+	n.local = t.Mul4(n.rotation.Mat4()).Mul4(s)
+	n.markDirty()
+}
+
+// Invalidates the world matrix of the node and its whole subtree
+// A dirty node always has dirty descendants, so the walk can stop there
+func (n *Node) markDirty() {
+	if n.dirty {
+		return
+	}
+	n.dirty = true
+	for _, c := range n.Children {
+		c.markDirty()
+	}
+}
@@ -2,7 +2,6 @@ package scene
 
 import (
 	"fmt"
-	"log"
 	"math"
 
 	"github.com/go-gl/mathgl/mgl32"
@@ -15,171 +14,343 @@ const (
 	FAR  = 100.0
 )
 
+// Number of floats a point light takes in the storage buffer
+// Every field is padded to a vec4 to match the std430 layout
+const pointLightSize = 20
+
+// Number of floats of the std140 Lights block:
+// the directional light, the spot light and the point light count
+const lightsBlockSize = 16 + 20 + 4
+
 type PointLight struct {
 	Position                    mgl32.Vec3
 	Ambient, Diffuse, Specular  mgl32.Vec3
 	Constant, Linear, Quadratic float32
 }
+
+// Appends the std430 representation of the light to data
+func (l *PointLight) pack(data []float32) []float32 {
+	return append(data,
+		l.Position[0], l.Position[1], l.Position[2], 0,
+		l.Ambient[0], l.Ambient[1], l.Ambient[2], 0,
+		l.Diffuse[0], l.Diffuse[1], l.Diffuse[2], 0,
+		l.Specular[0], l.Specular[1], l.Specular[2], 0,
+		l.Constant, l.Linear, l.Quadratic, 0,
+	)
+}
+
+type DirLight struct {
+	Direction                  mgl32.Vec3
+	Ambient, Diffuse, Specular mgl32.Vec3
+}
+
+// Appends the std140 representation of the light to data
+func (l *DirLight) pack(data []float32) []float32 {
+	return append(data,
+		l.Direction[0], l.Direction[1], l.Direction[2], 0,
+		l.Ambient[0], l.Ambient[1], l.Ambient[2], 0,
+		l.Diffuse[0], l.Diffuse[1], l.Diffuse[2], 0,
+		l.Specular[0], l.Specular[1], l.Specular[2], 0,
+	)
+}
+
+// CutOff and OuterCutOff are cosines of the cone angles
+type SpotLight struct {
+	Position, Direction         mgl32.Vec3
+	Ambient, Diffuse, Specular  mgl32.Vec3
+	Constant, Linear, Quadratic float32
+	CutOff, OuterCutOff         float32
+}
+
+// Appends the std140 representation of the light to data
+// The scalars fill the padding after each vec3
+func (l *SpotLight) pack(data []float32) []float32 {
+	return append(data,
+		l.Position[0], l.Position[1], l.Position[2], l.CutOff,
+		l.Direction[0], l.Direction[1], l.Direction[2], l.OuterCutOff,
+		l.Ambient[0], l.Ambient[1], l.Ambient[2], l.Constant,
+		l.Diffuse[0], l.Diffuse[1], l.Diffuse[2], l.Linear,
+		l.Specular[0], l.Specular[1], l.Specular[2], l.Quadratic,
+	)
+}
+
 type Scene struct {
-	Nodes                []*Node
+	Root                 *Node   // Top of the scene graph, every node descends from it
+	Nodes                []*Node // All nodes of the graph, in creation order
+	Batches              []*Batch
 	Cam                  *Camera
 	DeltaTime, LastFrame float64
 	Perspective          mgl32.Mat4
+	AspectRatio          float32
 	lightPos             mgl32.Vec3
+	DirLight             DirLight
+	SpotLight            SpotLight
 	PointLights          []*PointLight
+	lightData            []float32 // Point lights as last uploaded
+	lightsUploaded       bool
 }
 
-// Holds all internal IDs for the VAO, Texture and Shader program
-type Node struct {
-	VaoID, TexID, ProgramID int
-	ModelMatrix             mgl32.Mat4
-	Renderable              bool
-	Position                mgl32.Vec3
-	Name                    string
-}
-
-// Creates a Node based on the data, texture and shader program
-// Receives a renderer, the data(VBO) the path to the texture we want
-// and a shader program by name (e.g. "basic", "phong")
+// Creates a Node based on the geometry and a material
+// Receives a renderer, either raw data or an existing mesh, and the material to draw it with
+// The node holds a reference to the mesh until it is removed
+// The material is checked against its program first, see Material.Validate,
+// only nodes that are not renderable may go without one
 // Returns a pointer to the created Node
-// TODO: Textures are loaded multiple times!
-func (s *Scene) NewNode(r *renderer.Renderer, name string, renderable bool, data []float32, texturePath, programName string, modelPos mgl32.Vec3) error {
-	programID, err := r.GetProgram(programName)
-	if err != nil {
-		return err
+func (s *Scene) NewNode(r *renderer.Renderer, name string, renderable bool, geom Geometry, mat *Material, modelPos mgl32.Vec3) (*Node, error) {
+	if mat == nil {
+		if renderable {
+			return nil, fmt.Errorf("node %q is renderable but has no material", name)
+		}
+	} else if err := mat.Validate(r); err != nil {
+		return nil, err
 	}
-
-	vaoID, err := r.LoadData(data)
+	mesh, err := geom.Acquire(r)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	texID, err := r.LoadTexture(texturePath, programID)
-	if err != nil {
-		return err
-	}
-	n := &Node{
-		Renderable: renderable,
-		VaoID:      vaoID,
-		TexID:      texID,
-		ProgramID:  programID,
-		Position:   modelPos,
-		Name:       name,
-	}
-	n.SetModelMatrix(mgl32.Translate3D(modelPos.X(), modelPos.Y(), modelPos.Z()))
+	n := NewEmptyNode(name)
+	n.Renderable = renderable
+	n.setMesh(mesh)
+	n.Material = mat
+	n.SetTranslation(modelPos)
 	s.attach(n)
-	return nil
+	return n, nil
 }
 
 // Take the same arguments as NewNode alongside with all the different model positions
 // This is useful when we having a single VAO with multiple transformations
-func (s *Scene) NewNodes(r *renderer.Renderer, name string, renderable bool, data []float32, texturePath, programName string, modelPositions []mgl32.Vec3) error {
-	programID, err := r.GetProgram(programName)
-	if err != nil {
-		return err
+// The nodes form a Batch, which Draw renders with a single instanced draw call
+func (s *Scene) NewNodes(r *renderer.Renderer, name string, renderable bool, geom Geometry, mat *Material, modelPositions []mgl32.Vec3) ([]*Node, error) {
+	if len(modelPositions) == 0 {
+		return nil, nil
 	}
-
-	vaoID, err := r.LoadData(data)
+	if mat == nil {
+		if renderable {
+			return nil, fmt.Errorf("node %q is renderable but has no material", name)
+		}
+	} else if err := mat.Validate(r); err != nil {
+		return nil, err
+	}
+	mesh, err := geom.Acquire(r)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	texID, err := r.LoadTexture(texturePath, programID)
-	if err != nil {
-		return err
+	// Every node holds a reference, all are taken before anything joins the scene
+	for i := 1; i < len(modelPositions); i++ {
+		if _, err := mesh.Acquire(r); err != nil {
+			for ; i > 0; i-- {
+				r.ReleaseMesh(mesh)
+			}
+			return nil, err
+		}
 	}
+
+	b := &Batch{Name: name, Mesh: mesh, Material: mat}
+	nodes := make([]*Node, 0, len(modelPositions))
 	for _, pos := range modelPositions {
-		node := &Node{
-			Renderable: renderable,
-			VaoID:      vaoID,
-			TexID:      texID,
-			ProgramID:  programID,
-			Position:   pos,
-			Name:       name,
-		}
-		node.ModelMatrix = mgl32.Translate3D(pos.X(), pos.Y(), pos.Z())
+		node := NewEmptyNode(name)
+		node.Renderable = renderable
+		node.setMesh(mesh)
+		node.Material = mat
+		node.SetTranslation(pos)
+		node.Batch = b
 		s.attach(node)
+		nodes = append(nodes, node)
 	}
-	return nil
-}
-
-func (n *Node) SetModelMatrix(model mgl32.Mat4) {
-	n.ModelMatrix = model
+	// The batch keeps its own slice, removing nodes must not shift the caller's
+	b.Nodes = append([]*Node(nil), nodes...)
+	s.Batches = append(s.Batches, b)
+	return nodes, nil
 }
 
 func NewScene(ratio float32, c *Camera, lights []*PointLight) *Scene {
 	proj := mgl32.Perspective(mgl32.DegToRad(FOV), ratio, NEAR, FAR)
 	return &Scene{
+		Root:        NewEmptyNode("root"),
 		Nodes:       make([]*Node, 0),
 		Cam:         c,
 		DeltaTime:   0,
 		LastFrame:   0,
 		Perspective: proj,
+		AspectRatio: ratio,
 		// lightPos:    lightPos,
+		DirLight: DirLight{
+			Direction: mgl32.Vec3{-0.2, -1.0, -0.3},
+			Ambient:   mgl32.Vec3{0.05, 0.05, 0.05},
+			Diffuse:   mgl32.Vec3{0.4, 0.4, 0.4},
+			Specular:  mgl32.Vec3{0.5, 0.5, 0.5},
+		},
+		SpotLight: SpotLight{
+			Position:    c.Position,
+			Direction:   c.Front,
+			Diffuse:     mgl32.Vec3{1.0, 1.0, 1.0},
+			Specular:    mgl32.Vec3{1.0, 1.0, 1.0},
+			Constant:    1.0,
+			Linear:      0.09,
+			Quadratic:   0.032,
+			CutOff:      float32(math.Cos(float64(mgl32.DegToRad(12.5)))),
+			OuterCutOff: float32(math.Cos(float64(mgl32.DegToRad(15)))),
+		},
 		PointLights: lights,
 	}
 }
 
+// Replaces the projection matrix, fovy is in radians
+func (s *Scene) SetPerspective(fovy, ratio, near, far float32) {
+	s.AspectRatio = ratio
+	s.Perspective = mgl32.Perspective(fovy, ratio, near, far)
+}
+
+// Creates a node without any geometry, e.g. to group other nodes under it
+func (s *Scene) NewGroup(name string, modelPos mgl32.Vec3) *Node {
+	n := NewEmptyNode(name)
+	n.SetTranslation(modelPos)
+	s.attach(n)
+	return n
+}
+
+// Adds a node to the scene, as a child of the root
+// Use AddChild on another node afterwards to move it deeper in the graph
 func (s *Scene) attach(n *Node) {
+	s.Root.AddChild(n)
 	s.Nodes = append(s.Nodes, n)
 }
 
-func (s *Scene) InitLights(r *renderer.Renderer) {
-	shaderID, err := r.GetProgram("phong")
-	if err != nil {
-		log.Fatalf("no such shader program: %q\n", err)
-	}
-	phongShader := r.Programs[shaderID]
-	phongShader.Bind()
-	// Prepare material's values
-	// That depends on what we are rendering!
-	// It shouldnt be in scene's initlights
-	phongShader.SetVec3f("material.ambient", 1.0, 0.5, 0.31)
-	phongShader.SetVec3f("material.diffuse", 1.0, 0.5, 0.31)
-	phongShader.SetVec3f("material.specular", 0.5, 0.5, 0.5)
-	phongShader.SetFloat("material.shininess", 32.0)
-
-	// Directional light
-	phongShader.SetVec3f("dirLight.direction", -0.2, -1.0, -0.3)
-	phongShader.SetVec3f("dirLight.ambient", 0.05, 0.05, 0.05)
-	phongShader.SetVec3f("dirLight.diffuse", 0.4, 0.4, 0.4)
-	phongShader.SetVec3f("dirLight.specular", 0.5, 0.5, 0.5)
-
-	// Spotlights
-	for i, light := range s.PointLights {
-		phongShader.SetVec3(fmt.Sprintf("pointLights[%d].position", i), light.Position)
-		phongShader.SetVec3(fmt.Sprintf("pointLights[%d].ambient", i), light.Ambient)
-		phongShader.SetVec3(fmt.Sprintf("pointLights[%d].diffuse", i), light.Diffuse)
-		phongShader.SetVec3(fmt.Sprintf("pointLights[%d].specular", i), light.Specular)
-		phongShader.SetFloat(fmt.Sprintf("pointLights[%d].constant", i), light.Constant)
-		phongShader.SetFloat(fmt.Sprintf("pointLights[%d].linear", i), light.Linear)
-		phongShader.SetFloat(fmt.Sprintf("pointLights[%d].quadratic", i), light.Quadratic)
-	}
-
-	phongShader.SetVec3("spotLight.position", s.Cam.Position)
-	phongShader.SetVec3("spotLight.direction", s.Cam.Front)
-	phongShader.SetVec3f("spotLight.ambient", 0.0, 0.0, 0.0)
-	phongShader.SetVec3f("spotLight.diffuse", 1.0, 1.0, 1.0)
-	phongShader.SetVec3f("spotLight.specular", 1.0, 1.0, 1.0)
-	phongShader.SetFloat("spotLight.constant", 1.0)
-	phongShader.SetFloat("spotLight.linear", 0.09)
-	phongShader.SetFloat("spotLight.quadratic", 0.032)
-	phongShader.SetFloat("spotLight.cutOff", float32(math.Cos(float64(mgl32.DegToRad(12.5)))))
-	phongShader.SetFloat("spotLight.outerCutOff", float32(math.Cos(float64(mgl32.DegToRad(15)))))
-}
-
-// NOTE: This looks meh..
-func (s *Scene) Update(r *renderer.Renderer) {
-	shaderID, err := r.GetProgram("phong")
-	if err != nil {
-		log.Fatalf("no such shader program: %q\n", err)
+// Removes a node and all of its descendants from the scene
+// Their references to meshes are given back to the renderer,
+// their materials are left to their owners, see Material.Release
+func (s *Scene) RemoveNode(r *renderer.Renderer, n *Node) {
+	if n.Parent != nil {
+		n.Parent.RemoveChild(n)
 	}
+	removed := make(map[*Node]bool)
+	n.Walk(func(c *Node) {
+		removed[c] = true
+		if c.Batch != nil {
+			c.Batch.remove(c)
+		}
+		if c.Mesh != nil {
+			r.ReleaseMesh(c.Mesh)
+			c.Mesh = nil
+		}
+	})
 
-	phongShader := r.Programs[shaderID]
-	phongShader.Bind()
-	phongShader.SetVec3("viewPos\x00", s.Cam.Position)
+	nodes := s.Nodes[:0]
+	for _, c := range s.Nodes {
+		if !removed[c] {
+			nodes = append(nodes, c)
+		}
+	}
+	s.Nodes = nodes
 
-	phongShader.SetVec3("spotLight.position", s.Cam.Position)
-	phongShader.SetVec3("spotLight.direction", s.Cam.Front)
+	batches := s.Batches[:0]
+	for _, b := range s.Batches {
+		if len(b.Nodes) > 0 {
+			batches = append(batches, b)
+		}
+	}
+	s.Batches = batches
+}
+
+// Draws all renderable nodes as seen from the camera at the last Update
+// Everything goes through the render queue, batches as a single instanced item
+// Nodes without a material (e.g. built by hand and added with AddNode) are skipped
+func (s *Scene) Draw(r *renderer.Renderer) {
+	for _, n := range s.Nodes {
+		if n.Renderable && n.Batch == nil && n.Mesh != nil && n.Material != nil {
+			r.Submit(renderer.DrawItem{VaoID: n.VaoID, Material: n.Material, Model: n.ModelMatrix(), Normal: n.NormalMatrix()})
+		}
+	}
+	for _, b := range s.Batches {
+		if b.Material == nil {
+			continue
+		}
+		if models, normals := b.ModelMatrices(); len(models) > 0 {
+			r.Submit(renderer.DrawItem{VaoID: b.Mesh.VaoID, Material: b.Material, Models: models, Normals: normals})
+		}
+	}
+	r.Flush()
+}
+
+// Adds an externally built node to the scene under parent
+// A nil parent attaches the node to the root
+func (s *Scene) AddNode(n, parent *Node) {
+	s.attach(n)
+	if parent != nil {
+		parent.AddChild(n)
+	}
+}
+
+// Adds a point light to the scene
+// It is uploaded along with the rest on the next Update
+func (s *Scene) AddPointLight(l *PointLight) {
+	s.PointLights = append(s.PointLights, l)
+}
+
+// Removes a point light from the scene
+// Returns false if the light was not part of the scene
+func (s *Scene) RemovePointLight(l *PointLight) bool {
+	for i, pl := range s.PointLights {
+		if pl == l {
+			s.PointLights = append(s.PointLights[:i], s.PointLights[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// Packs all point lights and uploads them if anything changed since the last upload
+// Lights are plain structs that can be edited anywhere, so comparing the packed
+// data is the only way to notice e.g. a moved light
+func (s *Scene) uploadPointLights(r *renderer.Renderer) {
+	data := make([]float32, 0, len(s.PointLights)*pointLightSize)
+	for _, l := range s.PointLights {
+		data = l.pack(data)
+	}
+	if s.lightsUploaded && equalFloats(data, s.lightData) {
+		return
+	}
+	r.UploadPointLights(data)
+	s.lightData = data
+	s.lightsUploaded = true
+}
+
+func equalFloats(a, b []float32) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Uploads the initial lighting, Update keeps it in sync afterwards
+func (s *Scene) InitLights(r *renderer.Renderer) {
+	s.uploadPointLights(r)
+	s.uploadLights(r)
+}
+
+// Packs the Lights block following std140
+func (s *Scene) uploadLights(r *renderer.Renderer) {
+	data := make([]float32, 0, lightsBlockSize)
+	data = s.DirLight.pack(data)
+	data = s.SpotLight.pack(data)
+	data = append(data, renderer.IntBits(int32(len(s.PointLights))), 0, 0, 0)
+	r.UploadLights(data)
+}
+
+// Uploads the per-frame data and all lights
+// The spot light follows the camera, like a flashlight
+func (s *Scene) Update(r *renderer.Renderer) {
+	r.UploadFrame(s.Cam.GetViewMatrix(), s.Perspective, s.Cam.Position, float32(s.LastFrame))
 
+	s.SpotLight.Position = s.Cam.Position
+	s.SpotLight.Direction = s.Cam.Front
+	s.uploadLights(r)
+	s.uploadPointLights(r)
 }
@@ -0,0 +1,236 @@
+package scene
+
+import (
+	"encoding/binary"
+	"math"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/go-gl/gl/v4.3-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/linosgian/goph3d/renderer"
+)
+
+// Creates a renderer on a Recorder, restoring the GL backend when the test ends
+// Shaders are read relative to the repository root, the tests run in scene/
+func testRenderer(t *testing.T) (*renderer.Renderer, *renderer.Recorder) {
+	t.Helper()
+	if os.Getenv("PROJ_PATH") == "" {
+		wd, err := os.Getwd()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chdir(".."); err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { os.Chdir(wd) })
+	}
+	rec := renderer.NewRecorder()
+	prev := renderer.CurrentBackend()
+	renderer.SetBackend(rec)
+	t.Cleanup(func() { renderer.SetBackend(prev) })
+
+	r, err := renderer.NewRenderer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec.Reset()
+	return r, rec
+}
+
+// Floats as the little-endian bytes a buffer upload carries
+func floatBytes(fs []float32) []byte {
+	b := make([]byte, 4*len(fs))
+	for i, f := range fs {
+		binary.LittleEndian.PutUint32(b[4*i:], math.Float32bits(f))
+	}
+	return b
+}
+
+// Returns the uploads, BufferData or BufferSubData, made to a buffer target
+func uploads(rec *renderer.Recorder, name string, target uint32) []renderer.Command {
+	var cmds []renderer.Command
+	for _, c := range rec.Calls(name) {
+		if c.Args[0] == target {
+			cmds = append(cmds, c)
+		}
+	}
+	return cmds
+}
+
+func TestInitLights(t *testing.T) {
+	r, rec := testRenderer(t)
+	lights := []*PointLight{
+		{Position: mgl32.Vec3{1, 2, 3}, Ambient: mgl32.Vec3{0.1, 0.1, 0.1}, Diffuse: mgl32.Vec3{1, 1, 1}, Specular: mgl32.Vec3{1, 1, 1}, Constant: 1, Linear: 0.09, Quadratic: 0.032},
+		{Position: mgl32.Vec3{-1, 0, 0}, Diffuse: mgl32.Vec3{0, 0, 1}, Constant: 1},
+	}
+	s := NewScene(16.0/9, NewCamera(mgl32.Vec3{0, 0, 3}, 0, 0), lights)
+	s.InitLights(r)
+
+	ssbo := uploads(rec, "BufferData", gl.SHADER_STORAGE_BUFFER)
+	if len(ssbo) != 1 {
+		t.Fatalf("got %v uploading the point lights, want a single BufferData", ssbo)
+	}
+	want := []float32{
+		1, 2, 3, 0,
+		0.1, 0.1, 0.1, 0,
+		1, 1, 1, 0,
+		1, 1, 1, 0,
+		1, 0.09, 0.032, 0,
+
+		-1, 0, 0, 0,
+		0, 0, 0, 0,
+		0, 0, 1, 0,
+		0, 0, 0, 0,
+		1, 0, 0, 0,
+	}
+	if size := ssbo[0].Args[1]; size != 2*pointLightSize*4 {
+		t.Errorf("point lights take %v bytes, want %d", size, 2*pointLightSize*4)
+	}
+	if !reflect.DeepEqual(ssbo[0].Args[2], floatBytes(want)) {
+		t.Errorf("point lights were packed as %v", ssbo[0].Args[2])
+	}
+	if bases := rec.Calls("BindBufferBase"); len(bases) == 0 || bases[0].Args[0] != uint32(gl.SHADER_STORAGE_BUFFER) || bases[0].Args[1] != renderer.PointLightsBinding {
+		t.Errorf("the point lights were not bound to their binding: %v", bases)
+	}
+
+	ubo := uploads(rec, "BufferData", gl.UNIFORM_BUFFER)
+	if len(ubo) != 1 {
+		t.Fatalf("got %v uploading the Lights block, want a single BufferData", ubo)
+	}
+	block := ubo[0].Args[2].([]byte)
+	if len(block) != lightsBlockSize*4 {
+		t.Fatalf("the Lights block takes %d bytes, want %d", len(block), lightsBlockSize*4)
+	}
+	// The point light count is an int, at the end of the block
+	if n := int32(binary.LittleEndian.Uint32(block[36*4:])); n != 2 {
+		t.Errorf("the Lights block counts %d point lights, want 2", n)
+	}
+	if cutOff := math.Float32frombits(binary.LittleEndian.Uint32(block[19*4:])); cutOff != s.SpotLight.CutOff {
+		t.Errorf("the spot light cut off is %v, want %v", cutOff, s.SpotLight.CutOff)
+	}
+}
+
+func TestUpdatePointLights(t *testing.T) {
+	r, rec := testRenderer(t)
+	l := &PointLight{Position: mgl32.Vec3{1, 2, 3}, Constant: 1}
+	s := NewScene(1, NewCamera(mgl32.Vec3{0, 0, 3}, 0, 0), []*PointLight{l})
+	s.InitLights(r)
+
+	// Unchanged lights are not uploaded again
+	rec.Reset()
+	s.Update(r)
+	if got := uploads(rec, "BufferSubData", gl.SHADER_STORAGE_BUFFER); len(got) != 0 {
+		t.Errorf("unchanged point lights were uploaded: %v", got)
+	}
+
+	// A moved light fits the buffer, so only its contents are replaced
+	l.Position = mgl32.Vec3{4, 5, 6}
+	rec.Reset()
+	s.Update(r)
+	if got := uploads(rec, "BufferData", gl.SHADER_STORAGE_BUFFER); len(got) != 0 {
+		t.Errorf("the point light buffer was reallocated: %v", got)
+	}
+	sub := uploads(rec, "BufferSubData", gl.SHADER_STORAGE_BUFFER)
+	if len(sub) != 1 {
+		t.Fatalf("got %v, want a single BufferSubData", sub)
+	}
+	want := []interface{}{uint32(gl.SHADER_STORAGE_BUFFER), 0, pointLightSize * 4, floatBytes(l.pack(nil))}
+	if !reflect.DeepEqual(sub[0].Args, want) {
+		t.Errorf("got %v, want BufferSubData%v", sub[0], want)
+	}
+
+	// Another light does not fit anymore
+	s.AddPointLight(&PointLight{Constant: 1})
+	rec.Reset()
+	s.Update(r)
+	if got := uploads(rec, "BufferData", gl.SHADER_STORAGE_BUFFER); len(got) != 1 || got[0].Args[1] != 2*pointLightSize*4 {
+		t.Errorf("got %v adding a point light", got)
+	}
+}
+
+// A single triangle in the default layout
+var triangle = RawGeometry{Vertices: []float32{
+	0, 0, 0, 0, 0, 0, 0, 1,
+	1, 0, 0, 1, 0, 0, 0, 1,
+	0, 1, 0, 0, 1, 0, 0, 1,
+}}
+
+func TestNodesWithoutMaterial(t *testing.T) {
+	r, rec := testRenderer(t)
+	s := NewScene(1, NewCamera(mgl32.Vec3{0, 0, 3}, 0, 0), nil)
+
+	if _, err := s.NewNode(r, "tri", true, triangle, nil, mgl32.Vec3{}); err == nil {
+		t.Error("a renderable node was created without a material")
+	}
+	if _, err := s.NewNodes(r, "tris", true, triangle, nil, []mgl32.Vec3{{}, {1, 0, 0}}); err == nil {
+		t.Error("renderable nodes were created without a material")
+	}
+	if len(s.Nodes) != 0 || len(s.Batches) != 0 {
+		t.Fatalf("failed calls left %d nodes and %d batches", len(s.Nodes), len(s.Batches))
+	}
+
+	// Nodes that are only there to be seen by others, or built by hand, are skipped
+	hidden, err := s.NewNode(r, "hidden", false, triangle, nil, mgl32.Vec3{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	manual := NewEmptyNode("manual")
+	manual.Renderable = true
+	manual.setMesh(hidden.Mesh)
+	s.AddNode(manual, nil)
+	s.Batches = append(s.Batches, &Batch{Name: "manual", Mesh: hidden.Mesh, Nodes: []*Node{manual}})
+
+	mat, err := NewMaterial(r, "basic", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.NewNode(r, "drawn", true, triangle, mat, mgl32.Vec3{}); err != nil {
+		t.Fatal(err)
+	}
+
+	rec.Reset()
+	s.Draw(r)
+	if draws := rec.Calls("DrawArrays"); len(draws) != 1 {
+		t.Errorf("got %v, want only the node with a material drawn", draws)
+	}
+}
+
+// Hands out a mesh without taking a reference, so acquiring it again fails once it was released
+type staleGeometry struct{ mesh *renderer.Mesh }
+
+func (g staleGeometry) Acquire(r *renderer.Renderer) (*renderer.Mesh, error) {
+	return g.mesh, nil
+}
+
+func TestNewNodesReferences(t *testing.T) {
+	r, rec := testRenderer(t)
+	s := NewScene(1, NewCamera(mgl32.Vec3{0, 0, 3}, 0, 0), nil)
+	positions := []mgl32.Vec3{{}, {1, 0, 0}, {2, 0, 0}}
+
+	mesh, err := triangle.Acquire(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.ReleaseMesh(mesh)
+	if _, err := s.NewNodes(r, "stale", false, staleGeometry{mesh}, nil, positions); err == nil {
+		t.Error("nodes were created from a released mesh")
+	}
+	if len(s.Nodes) != 0 || len(s.Root.Children) != 0 || len(s.Batches) != 0 {
+		t.Fatalf("the failed call left %d nodes and %d batches", len(s.Nodes), len(s.Batches))
+	}
+
+	// Every node holds a reference, the mesh goes away with the last one
+	nodes, err := s.NewNodes(r, "tris", false, triangle, nil, positions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec.Reset()
+	for i, n := range nodes {
+		s.RemoveNode(r, n)
+		if del := len(rec.Calls("DeleteVertexArrays")); (del == 1) != (i == len(nodes)-1) {
+			t.Fatalf("the mesh was deleted %d times after removing %d of %d nodes", del, i+1, len(nodes))
+		}
+	}
+}
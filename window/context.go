@@ -0,0 +1,34 @@
+package window
+
+import (
+	"github.com/go-gl/glfw/v3.2/glfw"
+)
+
+// A GL context to draw frames with, either a window or an offscreen one, see InitHeadless
+type Context interface {
+	Size() (width, height int) // In pixels
+	Time() float64             // Seconds since the context was created
+	Clear()
+	EndFrame() // Presents the frame
+	ShouldClose() bool
+	Destroy()
+}
+
+var (
+	_ Context = (*GlWindow)(nil)
+	_ Context = (*Headless)(nil)
+)
+
+func (gw *GlWindow) Size() (int, int) {
+	return gw.GetFramebufferSize()
+}
+
+func (gw *GlWindow) Time() float64 {
+	return glfw.GetTime()
+}
+
+// Shows the frame and handles the input that came in meanwhile
+func (gw *GlWindow) EndFrame() {
+	gw.SwapBuffers()
+	glfw.PollEvents()
+}
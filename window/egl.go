@@ -0,0 +1,101 @@
+//go:build egl
+// +build egl
+
+package window
+
+/*
+#cgo LDFLAGS: -lEGL
+#include <stdlib.h>
+#include <EGL/egl.h>
+#include <EGL/eglext.h>
+
+// Function pointers from extensions can't be called from Go directly
+static EGLDisplay getPlatformDisplay(void *proc, EGLenum platform) {
+	return ((PFNEGLGETPLATFORMDISPLAYEXTPROC)proc)(platform, EGL_DEFAULT_DISPLAY, NULL);
+}
+*/
+import "C"
+
+import (
+	"fmt"
+	"unsafe"
+)
+
+// A surfaceless EGL context, frames go to framebuffer objects only
+// go-gl looks functions up through EGL as well when built with the egl tag
+type eglContext struct {
+	display C.EGLDisplay
+	context C.EGLContext
+}
+
+func newEGLContext() (*eglContext, error) {
+	c := &eglContext{display: surfacelessDisplay()}
+	if c.display == C.EGLDisplay(C.EGL_NO_DISPLAY) {
+		return nil, fmt.Errorf("no EGL display available")
+	}
+	var major, minor C.EGLint
+	if C.eglInitialize(c.display, &major, &minor) == C.EGL_FALSE {
+		return nil, fmt.Errorf("could not initialize EGL: %s", eglError())
+	}
+	if C.eglBindAPI(C.EGL_OPENGL_API) == C.EGL_FALSE {
+		C.eglTerminate(c.display)
+		return nil, fmt.Errorf("EGL has no desktop OpenGL: %s", eglError())
+	}
+
+	// Surfaceless displays only have pbuffer configs, while eglChooseConfig looks for window ones by default
+	configAttribs := []C.EGLint{
+		C.EGL_SURFACE_TYPE, C.EGL_PBUFFER_BIT,
+		C.EGL_RENDERABLE_TYPE, C.EGL_OPENGL_BIT,
+		C.EGL_NONE,
+	}
+	var config C.EGLConfig
+	var n C.EGLint
+	if C.eglChooseConfig(c.display, &configAttribs[0], &config, 1, &n) == C.EGL_FALSE || n == 0 {
+		C.eglTerminate(c.display)
+		return nil, fmt.Errorf("no EGL config for OpenGL: %s", eglError())
+	}
+
+	// Same version and profile as the window, see initGLFW
+	contextAttribs := []C.EGLint{
+		C.EGL_CONTEXT_MAJOR_VERSION, 4,
+		C.EGL_CONTEXT_MINOR_VERSION, 3,
+		C.EGL_CONTEXT_OPENGL_PROFILE_MASK, C.EGL_CONTEXT_OPENGL_CORE_PROFILE_BIT,
+		C.EGL_NONE,
+	}
+	c.context = C.eglCreateContext(c.display, config, C.EGLContext(C.EGL_NO_CONTEXT), &contextAttribs[0])
+	if c.context == C.EGLContext(C.EGL_NO_CONTEXT) {
+		C.eglTerminate(c.display)
+		return nil, fmt.Errorf("could not create an OpenGL 4.3 core context: %s", eglError())
+	}
+	// Needs EGL_KHR_surfaceless_context, which Mesa and the proprietary drivers have
+	noSurface := C.EGLSurface(C.EGL_NO_SURFACE)
+	if C.eglMakeCurrent(c.display, noSurface, noSurface, c.context) == C.EGL_FALSE {
+		c.destroy()
+		return nil, fmt.Errorf("could not make the EGL context current: %s", eglError())
+	}
+	return c, nil
+}
+
+// Prefers Mesa's surfaceless platform, which needs no display server at all
+func surfacelessDisplay() C.EGLDisplay {
+	name := C.CString("eglGetPlatformDisplayEXT")
+	defer C.free(unsafe.Pointer(name))
+	if proc := C.eglGetProcAddress(name); proc != nil {
+		d := C.getPlatformDisplay(unsafe.Pointer(proc), C.EGL_PLATFORM_SURFACELESS_MESA)
+		if d != C.EGLDisplay(C.EGL_NO_DISPLAY) {
+			return d
+		}
+	}
+	return C.eglGetDisplay(C.EGLNativeDisplayType(C.EGL_DEFAULT_DISPLAY))
+}
+
+func eglError() string {
+	return fmt.Sprintf("EGL error 0x%X", int(C.eglGetError()))
+}
+
+func (c *eglContext) destroy() {
+	noSurface := C.EGLSurface(C.EGL_NO_SURFACE)
+	C.eglMakeCurrent(c.display, noSurface, noSurface, C.EGLContext(C.EGL_NO_CONTEXT))
+	C.eglDestroyContext(c.display, c.context)
+	C.eglTerminate(c.display)
+}
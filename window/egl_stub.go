@@ -0,0 +1,16 @@
+//go:build !egl
+// +build !egl
+
+package window
+
+import (
+	"fmt"
+)
+
+type eglContext struct{}
+
+func newEGLContext() (*eglContext, error) {
+	return nil, fmt.Errorf("headless mode needs a build with -tags egl")
+}
+
+func (c *eglContext) destroy() {}
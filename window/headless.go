@@ -0,0 +1,73 @@
+package window
+
+import (
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/linosgian/goph3d/renderer"
+)
+
+// Time that passes between two headless frames, so runs are reproducible
+const headlessFrameTime = 1.0 / 60
+
+// An offscreen context without a window, e.g. for servers and CI
+// Everything is drawn into a framebuffer, which stays bound
+type Headless struct {
+	ctx    *eglContext
+	fb     *renderer.Framebuffer
+	frames int // Frames drawn so far
+	limit  int // Frames to draw before ShouldClose, 0 for no limit
+}
+
+// Creates an offscreen context of the given size through EGL (e.g. Mesa's llvmpipe)
+// It closes after the given number of frames, 0 keeps it open until Destroy
+// Needs a build with -tags egl
+func InitHeadless(width, height, frames int) (*Headless, error) {
+	ctx, err := newEGLContext()
+	if err != nil {
+		return nil, err
+	}
+	if err := initOpenGL(); err != nil {
+		ctx.destroy()
+		return nil, err
+	}
+	fb, err := renderer.NewFramebuffer(renderer.DefaultFramebufferSpec(int32(width), int32(height)))
+	if err != nil {
+		ctx.destroy()
+		return nil, err
+	}
+	fb.Bind()
+	return &Headless{ctx: ctx, fb: fb, limit: frames}, nil
+}
+
+func (h *Headless) Size() (int, int) {
+	return int(h.fb.Width()), int(h.fb.Height())
+}
+
+// Time advances by a fixed step per frame, however long frames take to draw
+func (h *Headless) Time() float64 {
+	return float64(h.frames) * headlessFrameTime
+}
+
+func (h *Headless) Clear() {
+	h.fb.Clear(mgl32.Vec4{0.1, 0.1, 0.1, 1.0})
+}
+
+// Waits for the frame to be drawn
+func (h *Headless) EndFrame() {
+	renderer.CurrentBackend().Finish()
+	h.frames++
+}
+
+func (h *Headless) ShouldClose() bool {
+	return h.limit > 0 && h.frames >= h.limit
+}
+
+// The framebuffer frames are drawn into
+func (h *Headless) Framebuffer() *renderer.Framebuffer {
+	return h.fb
+}
+
+func (h *Headless) Destroy() {
+	h.fb.Unbind()
+	h.fb.Delete()
+	h.ctx.destroy()
+}
@@ -7,6 +7,7 @@ import (
 
 	"github.com/go-gl/gl/v4.3-core/gl"
 	"github.com/go-gl/glfw/v3.2/glfw"
+	"github.com/linosgian/goph3d/renderer"
 	"github.com/linosgian/goph3d/scene"
 )
 
@@ -43,8 +44,8 @@ func initGLFW() (*GlWindow, error) {
 
 	// Specify profile and OpenGL version
 	glfw.WindowHint(glfw.Resizable, glfw.False)
-	glfw.WindowHint(glfw.ContextVersionMajor, 4) // OR 2
-	glfw.WindowHint(glfw.ContextVersionMinor, 1)
+	glfw.WindowHint(glfw.ContextVersionMajor, 4)
+	glfw.WindowHint(glfw.ContextVersionMinor, 3) // Shader storage buffers need 4.3
 	glfw.WindowHint(glfw.OpenGLProfile, glfw.OpenGLCoreProfile)
 	glfw.WindowHint(glfw.OpenGLForwardCompatible, glfw.True)
 
@@ -67,8 +68,8 @@ func initOpenGL() error {
 	log.Println("OpenGL version", version)
 
 	// Enable debugging and hook callback
-	gl.Enable(gl.DEBUG_OUTPUT)
-	gl.DebugMessageCallback(Debug, nil)
+	renderer.CurrentBackend().Enable(gl.DEBUG_OUTPUT)
+	renderer.CurrentBackend().DebugMessageCallback(Debug, nil)
 	return nil
 }
 
@@ -95,6 +96,6 @@ func (gw *GlWindow) Destroy() {
 }
 
 func (gw *GlWindow) Clear() {
-	gl.ClearColor(0.1, 0.1, 0.1, 1.0) // Default scene color.
-	gl.Clear(gl.COLOR_BUFFER_BIT | gl.DEPTH_BUFFER_BIT)
+	renderer.CurrentBackend().ClearColor(0.1, 0.1, 0.1, 1.0) // Default scene color, hidden by the skybox if there is one
+	renderer.CurrentBackend().Clear(gl.COLOR_BUFFER_BIT | gl.DEPTH_BUFFER_BIT)
 }